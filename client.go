@@ -82,7 +82,13 @@ func (c *Client) Get() error {
 		}
 	}
 
-	src, err := Detect(c.Src, c.Pwd, c.Detectors)
+	// Sources may be forced to any of the client's getters.
+	tokens := make([]string, 0, len(c.Getters))
+	for token := range c.Getters {
+		tokens = append(tokens, token)
+	}
+
+	src, err := DetectContext(c.Ctx, c.Src, c.Pwd, c.Detectors, WithGetterTokens(tokens...))
 	if err != nil {
 		return err
 	}
@@ -23,7 +23,7 @@ func (c *Client) Configure(opts ...ClientOption) error {
 	}
 	// Default detector values
 	if c.Detectors == nil {
-		c.Detectors = Detectors
+		c.Detectors = registeredDetectors()
 	}
 	// Default getter values
 	if c.Getters == nil {
@@ -1,8 +1,13 @@
 package getter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-getter/helper/url"
 )
@@ -16,14 +21,270 @@ type Detector interface {
 	Detect(string, string) (string, bool, error)
 }
 
+// ContextDetector is an optional interface that a Detector can implement
+// to receive the context passed to DetectContext. Detectors that perform
+// network calls should implement it so that callers can cancel them.
+type ContextDetector interface {
+	Detector
+
+	// DetectContext is like Detect but honors cancellation and deadlines
+	// of the given context.
+	DetectContext(context.Context, string, string) (string, bool, error)
+}
+
+// ErrNoMatch is matched by errors.Is for errors returned by detection when
+// no detector matched the source. Errors from the detectors themselves
+// are returned as-is.
+var ErrNoMatch = errors.New("invalid source string")
+
+// NoMatchError is the error returned by detection when no detector
+// matched the source.
+type NoMatchError struct {
+	// Src is the source that wasn't matched.
+	Src string
+
+	// Skipped are the names of the detectors that were skipped because
+	// they need the network, as with WithOffline.
+	Skipped []string
+}
+
+func (e *NoMatchError) Error() string {
+	if len(e.Skipped) > 0 {
+		return fmt.Sprintf(
+			"invalid source string: %s (skipped detectors that need the network: %s)",
+			e.Src, strings.Join(e.Skipped, ", "))
+	}
+	return fmt.Sprintf("invalid source string: %s", e.Src)
+}
+
+// Is reports whether target is ErrNoMatch.
+func (e *NoMatchError) Is(target error) bool {
+	return target == ErrNoMatch
+}
+
+// NamedDetector is an optional interface that a Detector can implement to
+// give itself a stable name, such as "github". All built-in detectors
+// implement it.
+type NamedDetector interface {
+	Detector
+
+	// Name returns the name of the detector.
+	Name() string
+}
+
+// ForceTokenDetector is an optional interface that a Detector can
+// implement to claim forced getters, such as "ghid" in "ghid::12345". A
+// source forced to a claimed token is passed to the detector with the
+// token still in place, and the detector's result is used instead of
+// re-applying the token. A URL with the token as its scheme, such as
+// "oci://ghcr.io/org/module", is treated as forced to the token.
+//
+// Detection fails for a source forced to a token that no detector claims,
+// unless it names one of the Getters, so that a mistyped token such as
+// "gti::" is caught rather than passed on.
+type ForceTokenDetector interface {
+	Detector
+
+	// ForceTokens returns the forced getters claimed by the detector.
+	ForceTokens() []string
+}
+
+// NetworkDetector is an optional interface that a Detector can implement
+// to report whether it makes network requests, such as API lookups. Such
+// detectors are skipped by WithOffline.
+type NetworkDetector interface {
+	Detector
+
+	// NeedsNetwork reports whether the detector makes network requests.
+	NeedsNetwork() bool
+}
+
+// ExplainDetector is an optional interface that a Detector can implement to
+// tell why it didn't match a source, as reported by DetectExplain.
+type ExplainDetector interface {
+	Detector
+
+	// ExplainDetect is like Detect but also returns a human-readable
+	// reason when the source doesn't match.
+	ExplainDetect(string, string) (string, bool, string, error)
+}
+
+// URLDetector is an optional interface that a Detector can implement to
+// also see sources that are already valid URLs, such as
+// "ssh://github.com/org/repo.git", which are otherwise used as they are.
+// The first URLDetector that matches replaces the URL with its result.
+type URLDetector interface {
+	Detector
+
+	// DetectURL is like Detect but is given a valid URL, along with any
+	// forced getter, and returns whether it rewrote it.
+	DetectURL(string) (string, bool, error)
+}
+
+// SubdirDetector is an optional interface that a Detector can implement to
+// see the subdir given in the source, such as "v1/mod" in
+// "example.com/repo//v1/mod", and replace it. Other detectors never see
+// the subdir, which is re-applied to their result unchanged.
+//
+// If the detector matches, the subdir it returns replaces the one from the
+// source, and an empty subdir removes it. A subdir in the detected source
+// itself is then prepended to it, as for any other detector.
+type SubdirDetector interface {
+	Detector
+
+	// DetectSubdir is like DetectContext but is also given the subdir from
+	// the source, and returns the subdir to use in its place.
+	DetectSubdir(ctx context.Context, src, subDir, pwd string) (string, string, bool, error)
+}
+
 // Detectors is the list of detectors that are tried on an invalid URL.
 // This is also the order they're tried (index 0 is first).
+//
+// To change it while detection may be running, use RegisterDetector,
+// RegisterDetectorAt, MoveDetectorBefore, MoveDetectorToFront and
+// ResetDetectors rather than modifying it directly.
 var Detectors []Detector
 
+// detectorsLock guards Detectors.
+var detectorsLock sync.RWMutex
+
 func init() {
-	Detectors = []Detector{
+	Detectors = defaultDetectors()
+}
+
+// RegisterDetector adds d to the end of the global Detectors.
+//
+// Like RegisterDetectorAt and ResetDetectors, this is safe to call while
+// detection is running: Detectors is replaced rather than modified in
+// place, so detection that already started keeps the list it was given.
+func RegisterDetector(d Detector) {
+	detectorsLock.Lock()
+	defer detectorsLock.Unlock()
+
+	ds := make([]Detector, 0, len(Detectors)+1)
+	ds = append(ds, Detectors...)
+	Detectors = append(ds, d)
+}
+
+// RegisterDetectorAt inserts d into the global Detectors at index i, so
+// that it is tried before the detector that was at i. i may be
+// len(Detectors) to add d at the end.
+func RegisterDetectorAt(i int, d Detector) error {
+	detectorsLock.Lock()
+	defer detectorsLock.Unlock()
+
+	if i < 0 || i > len(Detectors) {
+		return fmt.Errorf(
+			"detector index %d out of range [0, %d]", i, len(Detectors))
+	}
+
+	ds := make([]Detector, 0, len(Detectors)+1)
+	ds = append(ds, Detectors[:i]...)
+	ds = append(ds, d)
+	Detectors = append(ds, Detectors[i:]...)
+	return nil
+}
+
+// ResetDetectors restores the global Detectors to the defaults.
+func ResetDetectors() {
+	detectorsLock.Lock()
+	defer detectorsLock.Unlock()
+
+	Detectors = defaultDetectors()
+}
+
+// DetectorNames returns the names of the global Detectors in the order
+// they're tried. Detectors that aren't a NamedDetector are named by their
+// type, such as "*main.MyDetector".
+func DetectorNames() []string {
+	ds := registeredDetectors()
+
+	names := make([]string, len(ds))
+	for i, d := range ds {
+		names[i] = detectorLabel(d)
+	}
+	return names
+}
+
+// MoveDetectorBefore moves the global detector with the given name, as
+// reported by DetectorNames, so that it is tried right before the one
+// named before. If several detectors have a name, the first is used.
+func MoveDetectorBefore(name, before string) error {
+	detectorsLock.Lock()
+	defer detectorsLock.Unlock()
+
+	i := indexDetector(Detectors, name)
+	if i == -1 {
+		return fmt.Errorf("no detector named %q", name)
+	}
+	if indexDetector(Detectors, before) == -1 {
+		return fmt.Errorf("no detector named %q", before)
+	}
+
+	d := Detectors[i]
+	ds := make([]Detector, 0, len(Detectors))
+	ds = append(ds, Detectors[:i]...)
+	ds = append(ds, Detectors[i+1:]...)
+
+	j := indexDetector(ds, before)
+	if j == -1 {
+		// The detector was to be moved before itself.
+		return nil
+	}
+	ds = append(ds[:j], append([]Detector{d}, ds[j:]...)...)
+	Detectors = ds
+	return nil
+}
+
+// MoveDetectorToFront moves the global detector with the given name, as
+// reported by DetectorNames, so that it is tried first. If several
+// detectors have the name, the first is used.
+func MoveDetectorToFront(name string) error {
+	detectorsLock.Lock()
+	defer detectorsLock.Unlock()
+
+	i := indexDetector(Detectors, name)
+	if i == -1 {
+		return fmt.Errorf("no detector named %q", name)
+	}
+
+	ds := make([]Detector, 0, len(Detectors))
+	ds = append(ds, Detectors[i])
+	ds = append(ds, Detectors[:i]...)
+	Detectors = append(ds, Detectors[i+1:]...)
+	return nil
+}
+
+// indexDetector returns the index of the first detector in ds with the
+// given name, or -1 if there is none.
+func indexDetector(ds []Detector, name string) int {
+	for i, d := range ds {
+		if detectorLabel(d) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// registeredDetectors returns the global Detectors.
+func registeredDetectors() []Detector {
+	detectorsLock.RLock()
+	defer detectorsLock.RUnlock()
+
+	return Detectors
+}
+
+// defaultDetectors returns a new list of the default detectors.
+func defaultDetectors() []Detector {
+	return []Detector{
 		new(GitHubDetector),
+		new(GitLabDetector),
+		new(SourceHutDetector),
+		new(HuggingFaceDetector),
+		new(LaunchpadDetector),
+		new(CodeCommitDetector),
 		new(GitDetector),
+		new(HgDetector),
 		new(BitBucketDetector),
 		new(S3Detector),
 		new(GCSDetector),
@@ -31,40 +292,383 @@ func init() {
 	}
 }
 
+// DefaultDetectorsExcept returns a new list of the default detectors, in
+// their default order, without those whose Name is one of names.
+func DefaultDetectorsExcept(names ...string) []Detector {
+	skip := make(map[string]bool, len(names))
+	for _, name := range names {
+		skip[name] = true
+	}
+
+	var ds []Detector
+	for _, d := range defaultDetectors() {
+		if skip[detectorName(d)] {
+			continue
+		}
+		ds = append(ds, d)
+	}
+
+	return ds
+}
+
+// detectorLabel returns the name of d, or its type if it isn't a
+// NamedDetector, for use in messages.
+func detectorLabel(d Detector) string {
+	if name := detectorName(d); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%T", d)
+}
+
+// detectorName returns the name of d if it is a NamedDetector, or an
+// empty string otherwise.
+func detectorName(d Detector) string {
+	if nd, ok := d.(NamedDetector); ok {
+		return nd.Name()
+	}
+	return ""
+}
+
 // Detect turns a source string into another source string if it is
 // detected to be of a known pattern.
 //
 // The third parameter should be the list of detectors to use in the
 // order to try them. If you don't want to configure this, just use
-// the global Detectors variable.
+// the global Detectors variable. Any DetectOptions are applied to the
+// detected source.
 //
 // This is safe to be called with an already valid source string: Detect
 // will just return it.
-func Detect(src string, pwd string, ds []Detector) (string, error) {
-	getForce, getSrc := getForcedGetter(src)
+//
+// A forced getter in src, such as "git::", takes precedence over any set
+// by a detector. If several are chained, such as "git::git::./foo", only
+// the outermost is kept.
+//
+// Likewise, query parameters given in src take precedence over any of the
+// same name that a detector adds as defaults, so "foo?ref=v1" keeps
+// "ref=v1" even if the detector sets another "ref".
+func Detect(src string, pwd string, ds []Detector, opts ...DetectOption) (string, error) {
+	return DetectContext(context.Background(), src, pwd, ds, opts...)
+}
 
-	// Separate out the subdir if there is one, we don't pass that to detect
-	getSrc, subDir := SourceDirSubdir(getSrc)
+// DetectPreferring is like Detect but tries the prefer detectors before
+// the rest. Neither list is modified, so this can be used to change the
+// order for a single call, such as with rest set to the global Detectors.
+func DetectPreferring(src string, pwd string, prefer []Detector, rest []Detector, opts ...DetectOption) (string, error) {
+	ds := make([]Detector, 0, len(prefer)+len(rest))
+	ds = append(ds, prefer...)
+	ds = append(ds, rest...)
+
+	return Detect(src, pwd, ds, opts...)
+}
+
+// DetectWithForce is like Detect for a source whose forced getter, such as
+// "git", is already known, as when it was parsed earlier or comes from
+// elsewhere. The source may repeat the same forced getter, as in
+// "git::./foo", but it is an error for it to give another. An empty force
+// is the same as calling Detect.
+func DetectWithForce(src string, force string, pwd string, ds []Detector, opts ...DetectOption) (string, error) {
+	force = strings.ToLower(strings.TrimSpace(force))
+	if force == "" {
+		return Detect(src, pwd, ds, opts...)
+	}
+
+	srcForce, rest := getForcedGetter(src)
+	if srcForce != "" && srcForce != force {
+		return "", fmt.Errorf(
+			"forced getter %q conflicts with %q in source: %s", force, srcForce, src)
+	}
+
+	return Detect(force+"::"+rest, pwd, ds, opts...)
+}
+
+// TryDetect is like Detect for callers that fall back to the source as
+// given. It returns the detected source and true, or src and false if
+// detection fails for any reason, including no detector matching. Panics
+// in a detector aren't recovered.
+func TryDetect(src string, pwd string, ds []Detector, opts ...DetectOption) (string, bool) {
+	result, err := Detect(src, pwd, ds, opts...)
+	if err != nil {
+		getLogger().Debugf("detection of %q failed: %s", src, err)
+		return src, false
+	}
+
+	return result, true
+}
+
+// DetectResult is the result of detection with the force token, subdir
+// and query split out of the detected source.
+type DetectResult struct {
+	// Force is the forced getter, such as "git", or empty if there is none.
+	Force string
+
+	// Source is the detected URL without the force token, subdir or query.
+	Source string
+
+	// Subdir is the subdirectory within Source, or empty if there is none.
+	Subdir string
+
+	// Query is the raw query string of the source, without the leading "?".
+	// Parameters given in the source keep their order and encoding, as for
+	// a file path forced to a getter such as "git::./foo?ref=v1&depth=1".
+	Query string
+}
+
+// String reassembles the result into a single source string of the form
+// accepted by Get.
+func (r *DetectResult) String() string {
+	result := r.Source
+	if r.Subdir != "" {
+		result += "//" + r.Subdir
+	}
+	if r.Query != "" {
+		result += "?" + r.Query
+	}
+	if r.Force != "" {
+		result = fmt.Sprintf("%s::%s", r.Force, result)
+	}
+
+	return result
+}
+
+// DetectContext is like Detect but carries a context. Detectors that
+// implement ContextDetector are given the context; others are called via
+// their Detect method. Detection stops with the context's error once it is
+// canceled.
+func DetectContext(ctx context.Context, src string, pwd string, ds []Detector, opts ...DetectOption) (string, error) {
+	r, err := DetectSplit(ctx, src, pwd, ds, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	return r.String(), nil
+}
+
+// DetectSplit is like DetectContext but returns the result with its
+// components split out, so callers don't have to parse them back out of
+// the source string.
+func DetectSplit(ctx context.Context, src string, pwd string, ds []Detector, opts ...DetectOption) (*DetectResult, error) {
+	return detectSplitExplain(ctx, src, pwd, ds, nil, opts...)
+}
+
+// DetectExplain is like Detect but also returns the reason each detector
+// that was tried gave for not matching the source, such as
+// "git: ssh detection yielded nil URL", to help tell why detection failed.
+// Detectors that don't implement ExplainDetector are reported as
+// "did not match".
+func DetectExplain(src, pwd string, ds []Detector, opts ...DetectOption) (string, []string, error) {
+	e := new(explainer)
+	r, err := detectSplitExplain(context.Background(), src, pwd, ds, e, opts...)
+	if err != nil {
+		return "", e.reasons, err
+	}
+	return r.String(), e.reasons, nil
+}
+
+// explainer collects the reasons that detectors give for not matching.
+type explainer struct {
+	reasons []string
+}
+
+// decline records that d didn't match for the given reason. It may be
+// called on a nil explainer, which records nothing.
+func (e *explainer) decline(d Detector, reason string) {
+	if e == nil {
+		return
+	}
+	if reason == "" {
+		reason = "did not match"
+	}
+	e.reasons = append(e.reasons, detectorLabel(d)+": "+reason)
+}
+
+// detectSplitExplain implements DetectSplit, recording the reasons that
+// detectors don't match in e if it's not nil.
+func detectSplitExplain(ctx context.Context, src string, pwd string, ds []Detector, e *explainer, opts ...DetectOption) (*DetectResult, error) {
+	var o detectOptions
+	if err := o.configure(opts...); err != nil {
+		return nil, err
+	}
+
+	pwd, err := o.resolvePwd(pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err = o.prepare(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.checkForceToken(src, ds); err != nil {
+		return nil, err
+	}
+
+	ds, skipped := o.filter(ds)
+
+	r, err := detectSplit(ctx, src, pwd, ds, e)
+	if err == nil && o.redetect {
+		r, err = redetect(ctx, r, pwd, ds, e)
+	}
+	if err != nil {
+		if noMatch, ok := err.(*NoMatchError); ok {
+			noMatch.Skipped = skipped
+		}
+		return nil, err
+	}
+	if err := o.validate(r, ds); err != nil {
+		return nil, err
+	}
+	o.finish(r)
+
+	return r, nil
+}
+
+// maxRedetect is the most times that WithRedetect runs detection on a
+// source.
+const maxRedetect = 8
+
+// redetect runs detection on the detected source r until it no longer
+// changes.
+func redetect(ctx context.Context, r *DetectResult, pwd string, ds []Detector, e *explainer) (*DetectResult, error) {
+	src := r.String()
+	for i := 1; i < maxRedetect; i++ {
+		next, err := detectSplit(ctx, src, pwd, ds, e)
+		if err != nil {
+			return nil, err
+		}
+		if next.String() == src {
+			return next, nil
+		}
+		src = next.String()
+	}
+
+	return nil, fmt.Errorf(
+		"detection loop exceeded %d passes, last detected source: %s",
+		maxRedetect, src)
+}
+
+func detectSplit(ctx context.Context, src string, pwd string, ds []Detector, e *explainer) (*DetectResult, error) {
+	getForce, getSrc, subDir := splitSource(trimEmptySuffix(src))
 
 	u, err := url.Parse(getSrc)
-	if err == nil && u.Scheme != "" {
+	if err == nil && u.Scheme != "" && (getForce == "" || getForce == u.Scheme) &&
+		strings.HasPrefix(strings.ToLower(getSrc), u.Scheme+"://") &&
+		Getters[u.Scheme] == nil && claimedByAny(ds, u.Scheme) {
+		// A URL whose scheme is a forced getter claimed by a detector, such
+		// as "oci://", is detected as if it were forced. Schemes that a
+		// getter handles as they are, such as "git://", are left alone.
+		getForce = u.Scheme
+		getSrc = getSrc[len(u.Scheme+"://"):]
+	} else if err == nil && u.Scheme != "" {
 		// Valid URL
-		return src, nil
+		if u.Scheme == "stdin" {
+			if err := validateStdinSource(u); err != nil {
+				return nil, err
+			}
+		}
+
+		// A composite "git+<scheme>" URL is the inner URL forced to git.
+		if isGitPlusScheme(u.Scheme) {
+			getSrc = getSrc[len("git+"):]
+			if getForce == "" {
+				getForce = "git"
+			}
+		}
+
+		if u.Scheme == "file" {
+			getSrc = normalizeFileURL(getSrc)
+		}
+
+		// Nothing understands "scp://", so it is taken to be Git over SSH.
+		// Since this is a URL, a colon after the host is a port rather
+		// than the SCP-like path separator.
+		if u.Scheme == "scp" {
+			getSrc = "ssh" + getSrc[len("scp"):]
+			if getForce == "" {
+				getForce = "git"
+			}
+		}
+
+		for _, d := range ds {
+			ud, ok := d.(URLDetector)
+			if !ok {
+				continue
+			}
+
+			detectSrc := getSrc
+			if getForce != "" {
+				detectSrc = getForce + "::" + getSrc
+			}
+			start := time.Now()
+			result, ok, err := ud.DetectURL(detectSrc)
+			runDetectHook(d, start, ok, err)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				getLogger().Debugf("detector %s detected %q as %q", detectorLabel(d), src, result)
+				getForce, getSrc = getForcedGetter(result)
+				break
+			}
+		}
+
+		source, query := splitQuery(getSrc)
+		return &DetectResult{
+			Force:  getForce,
+			Source: source,
+			Subdir: subDir,
+			Query:  query,
+		}, nil
 	}
 
 	for _, d := range ds {
-		result, ok, err := d.Detect(getSrc, pwd)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// A detector that claims the forced getter sees it, and its result
+		// replaces it.
+		detectSrc := getSrc
+		claimed := claimsForceToken(d, getForce)
+		if claimed {
+			detectSrc = getForce + "::" + getSrc
+		}
+
+		var result, reason string
+		var ok bool
+		start := time.Now()
+		if sd, isSubdir := d.(SubdirDetector); isSubdir {
+			var detectSubDir string
+			result, detectSubDir, ok, err = sd.DetectSubdir(ctx, detectSrc, subDir, pwd)
+			if ok {
+				subDir = detectSubDir
+			}
+		} else if ed, isExplain := d.(ExplainDetector); isExplain && e != nil {
+			result, ok, reason, err = ed.ExplainDetect(detectSrc, pwd)
+		} else {
+			result, ok, err = runDetector(ctx, d, detectSrc, pwd)
+		}
+		runDetectHook(d, start, ok, err)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		if !ok {
+			e.decline(d, reason)
 			continue
 		}
+		getLogger().Debugf("detector %s detected %q as %q", detectorLabel(d), src, result)
 
 		var detectForce string
 		detectForce, result = getForcedGetter(result)
 		result, detectSubdir := SourceDirSubdir(result)
 
+		// A file path forced to another getter, such as "git::./my repo",
+		// is passed on as a URL, so characters such as spaces and "#" in
+		// the path must be escaped.
+		if getForce != "" && getForce != "file" && strings.HasPrefix(result, "file://") {
+			result = escapeFileURL(result)
+		}
+
 		// If we have a subdir from the detection, then prepend it to our
 		// requested subdir.
 		if detectSubdir != "" {
@@ -75,31 +679,178 @@ func Detect(src string, pwd string, ds []Detector) (string, error) {
 			}
 		}
 
-		if subDir != "" {
+		var source, query string
+		if subDir != "" && !isSCPLike(result) {
 			u, err := url.Parse(result)
 			if err != nil {
-				return "", fmt.Errorf("Error parsing URL: %s", err)
+				return nil, fmt.Errorf("Error parsing URL: %s", err)
 			}
-			u.Path += "//" + subDir
 
-			// a subdir may contain wildcards, but in order to support them we
-			// have to ensure the path isn't escaped.
-			u.RawPath = u.Path
-
-			result = u.String()
+			// The subdir is appended as-is since it may contain wildcards
+			// that must not be escaped.
+			query = u.RawQuery
+			u.RawQuery = ""
+			source = u.String()
+		} else {
+			source, query = splitQuery(result)
 		}
 
+		// A detector may add default parameters, so merge back the
+		// parameters given in the source, which take precedence.
+		_, srcQuery := splitQuery(getSrc)
+		query = mergeQuery(query, srcQuery)
+
 		// Preserve the forced getter if it exists. We try to use the
 		// original set force first, followed by any force set by the
 		// detector.
-		if getForce != "" {
-			result = fmt.Sprintf("%s::%s", getForce, result)
-		} else if detectForce != "" {
-			result = fmt.Sprintf("%s::%s", detectForce, result)
+		force := getForce
+		if force == "" || claimed {
+			force = detectForce
+		}
+
+		// A forced file path such as "file::./foo" is detected as a file
+		// URL, which the file getter handles by scheme alone.
+		if force == "file" && strings.HasPrefix(source, "file://") {
+			force = ""
+		}
+
+		return &DetectResult{
+			Force:  force,
+			Source: source,
+			Subdir: subDir,
+			Query:  query,
+		}, nil
+	}
+
+	return nil, &NoMatchError{Src: src}
+}
+
+// mergeQuery merges the raw query string given in a source into the raw
+// query string detected for it. Parameters given in the source replace
+// all those of the same name in the detected query. Other parameters keep
+// their order and encoding, with those from the source last.
+func mergeQuery(detected, given string) string {
+	if given == "" || detected == given {
+		return detected
+	}
+
+	givenKeys := make(map[string]bool)
+	var params []string
+	for _, param := range strings.Split(given, "&") {
+		if param == "" {
+			continue
+		}
+		givenKeys[queryKey(param)] = true
+		params = append(params, param)
+	}
+
+	var merged []string
+	for _, param := range strings.Split(detected, "&") {
+		if param == "" || givenKeys[queryKey(param)] {
+			continue
 		}
+		merged = append(merged, param)
+	}
+
+	return strings.Join(append(merged, params...), "&")
+}
+
+// queryKey returns the name of a raw query parameter such as
+// "ref=v1.0.0".
+func queryKey(param string) string {
+	if idx := strings.Index(param, "="); idx > -1 {
+		return param[:idx]
+	}
+	return param
+}
+
+// isAbsPwd reports whether pwd is an absolute path. On Windows, a path
+// rooted on the current drive such as "\pwd" is accepted as well.
+func isAbsPwd(pwd string) bool {
+	return filepath.IsAbs(pwd) || strings.HasPrefix(filepath.ToSlash(pwd), "/")
+}
+
+// runDetector runs d on src, passing ctx along if d is a ContextDetector.
+func runDetector(ctx context.Context, d Detector, src, pwd string) (string, bool, error) {
+	if cd, ok := d.(ContextDetector); ok {
+		return cd.DetectContext(ctx, src, pwd)
+	}
+	return d.Detect(src, pwd)
+}
+
+// claimsForceToken reports whether d is a ForceTokenDetector that claims
+// the forced getter force.
+func claimsForceToken(d Detector, force string) bool {
+	fd, ok := d.(ForceTokenDetector)
+	if !ok || force == "" {
+		return false
+	}
+
+	for _, token := range fd.ForceTokens() {
+		if token == force {
+			return true
+		}
+	}
+	return false
+}
+
+// claimedByAny reports whether any of ds claims the forced getter force.
+func claimedByAny(ds []Detector, force string) bool {
+	for _, d := range ds {
+		if claimsForceToken(d, force) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSource splits src into its forced getter, the source that is passed
+// to detectors and the subdir.
+func splitSource(src string) (string, string, string) {
+	getForce, getSrc := getForcedGetter(src)
+
+	// Strip any further chained force tokens, such as in "git::git::./foo",
+	// so that detectors see the bare source. The outermost token takes
+	// precedence and is the one preserved in the result.
+	for {
+		innerForce, innerSrc := getForcedGetter(getSrc)
+		if innerForce == "" {
+			break
+		}
+		getSrc = innerSrc
+	}
+
+	// Separate out the subdir if there is one, we don't pass that to detect
+	getSrc, subDir := SourceDirSubdir(getSrc)
+
+	return getForce, getSrc, subDir
+}
+
+// trimEmptySuffix removes a trailing "?" or "#" with nothing after it, as
+// in "git@host:org/repo.git?", so that an empty query or fragment isn't
+// carried into the detected source.
+func trimEmptySuffix(src string) string {
+	for {
+		trimmed := strings.TrimSuffix(strings.TrimSuffix(src, "#"), "?")
+		if trimmed == src {
+			return src
+		}
+		src = trimmed
+	}
+}
+
+// splitQuery splits a source string into the part before the query and
+// the raw query string. The "?" of a Windows extended-length path prefix,
+// as in \\?\C:\foo, doesn't start a query.
+func splitQuery(src string) (string, string) {
+	var prefix string
+	if strings.HasPrefix(src, `\\?\`) {
+		prefix, src = src[:4], src[4:]
+	}
 
-		return result, nil
+	if idx := strings.Index(src, "?"); idx > -1 {
+		return prefix + src[:idx], src[idx+1:]
 	}
 
-	return "", fmt.Errorf("invalid source string: %s", src)
+	return prefix + src, ""
 }
@@ -0,0 +1,53 @@
+package getter
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-getter/helper/url"
+)
+
+// DetectionMatch is the outcome of running a single detector, as reported
+// by DetectAll.
+type DetectionMatch struct {
+	// Detector is the name of the detector, or its type if it isn't a
+	// NamedDetector.
+	Detector string
+
+	// OK, Result and Err are the values returned by the detector. Result
+	// is the raw detector output, without the subdir or force token from
+	// the source.
+	OK     bool
+	Result string
+	Err    error
+}
+
+// DetectAll runs every detector in ds on src and returns what each of them
+// produced, in order, without stopping at the first match. It is meant for
+// debugging which detectors claim a source; use Detect to actually detect
+// it. Network-based detectors can be left out of ds to avoid their calls.
+//
+// No detectors are run for a source that is already a valid URL.
+func DetectAll(ctx context.Context, src, pwd string, ds []Detector) ([]DetectionMatch, error) {
+	getForce, getSrc, _ := splitSource(src)
+	if u, err := url.Parse(getSrc); err == nil && u.Scheme != "" {
+		return nil, nil
+	}
+
+	matches := make([]DetectionMatch, 0, len(ds))
+	for _, d := range ds {
+		if err := ctx.Err(); err != nil {
+			return matches, err
+		}
+
+		detectSrc := getSrc
+		if claimsForceToken(d, getForce) {
+			detectSrc = getForce + "::" + getSrc
+		}
+
+		m := DetectionMatch{Detector: detectorLabel(d)}
+		m.Result, m.OK, m.Err = runDetector(ctx, d, detectSrc, pwd)
+		matches = append(matches, m)
+	}
+
+	return matches, nil
+}
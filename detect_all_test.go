@@ -0,0 +1,43 @@
+package getter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDetectAll(t *testing.T) {
+	ds := []Detector{
+		new(GitHubDetector),
+		new(GitDetector),
+		new(FileDetector),
+	}
+
+	matches, err := DetectAll(context.Background(), "github.com/hashicorp/foo//bar", "/pwd", ds)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("bad matches: %#v", matches)
+	}
+
+	expected := []DetectionMatch{
+		{Detector: "github", OK: true, Result: "git::https://github.com/hashicorp/foo.git"},
+		{Detector: "git", OK: false},
+		{Detector: "file", OK: true, Result: "file:///pwd/github.com/hashicorp/foo"},
+	}
+	for i, m := range matches {
+		if m != expected[i] {
+			t.Fatalf("%d: bad match: %#v\nexpected: %#v", i, m, expected[i])
+		}
+	}
+}
+
+func TestDetectAll_validURL(t *testing.T) {
+	matches, err := DetectAll(context.Background(), "https://example.com/foo", "/pwd", Detectors)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(matches) != 0 {
+		t.Fatalf("bad matches: %#v", matches)
+	}
+}
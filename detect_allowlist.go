@@ -0,0 +1,82 @@
+package getter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// HostAllowlist, if not empty, is the list of hosts that detected sources
+// may point at. Detection of a source for any other host fails with a
+// HostNotAllowedError. A pattern such as "*.internal.example.com" allows
+// any subdomain of "internal.example.com", but not that host itself.
+// Sources without a host, such as local files, are always allowed.
+//
+// It applies to every call that isn't given WithHostAllowlist, so it
+// should be set before detection starts.
+var HostAllowlist []string
+
+// ErrHostNotAllowed is matched by errors.Is for errors returned by
+// detection when the detected source points at a host that isn't allowed.
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// HostNotAllowedError is the error returned by detection when the detected
+// source points at a host that isn't in the allowlist.
+type HostNotAllowedError struct {
+	// Host is the host that isn't allowed.
+	Host string
+
+	// Src is the detected source.
+	Src string
+}
+
+func (e *HostNotAllowedError) Error() string {
+	return fmt.Sprintf("host %q is not allowed: %s", e.Host, e.Src)
+}
+
+// Is reports whether target is ErrHostNotAllowed.
+func (e *HostNotAllowedError) Is(target error) bool {
+	return target == ErrHostNotAllowed
+}
+
+// checkHostAllowed returns a HostNotAllowedError if the host of the
+// detected result r doesn't match any of the patterns.
+func checkHostAllowed(r *DetectResult, patterns []string) error {
+	host := sourceHost(r.Source)
+	if host == "" {
+		return nil
+	}
+
+	for _, pattern := range patterns {
+		if matchHost(host, pattern) {
+			return nil
+		}
+	}
+	return &HostNotAllowedError{Host: host, Src: r.String()}
+}
+
+// sourceHost returns the host, without a port, of a detected source, which
+// is either a URL or an SCP-like address.
+func sourceHost(src string) string {
+	if u, err := url.Parse(src); err == nil && u.Scheme != "" {
+		return u.Hostname()
+	}
+	if u, err := detectSSH(src); err == nil && u != nil {
+		return u.Hostname()
+	}
+	return ""
+}
+
+// matchHost reports whether host matches pattern, which is either a host
+// name or a wildcard such as "*.example.com". Host names are compared
+// without regard to case.
+func matchHost(host, pattern string) bool {
+	host = strings.ToLower(host)
+	pattern = strings.ToLower(pattern)
+
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return host == pattern
+}
@@ -0,0 +1,71 @@
+package getter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetect_hostAllowlist(t *testing.T) {
+	cases := []struct {
+		Input string
+		Err   bool
+	}{
+		{"github.com/hashicorp/foo", false},
+		{"GitHub.com/hashicorp/foo", false},
+		{"git@github.com:hashicorp/foo.git", false},
+		{"https://git.internal.example.com/team/repo.git", false},
+		{"https://a.b.internal.example.com:8443/foo.zip", false},
+		{"./foo", false},
+		{"file:///foo", false},
+		{"https://internal.example.com/foo.zip", true},
+		{"https://evil-internal.example.com/foo.zip", true},
+		{"gitlab.com/org/repo", true},
+		{"git::ssh://git@bitbucket.org/org/repo.git", true},
+		{"https://github.com.evil.com/foo.zip", true},
+	}
+
+	allowlist := WithHostAllowlist("github.com", "*.internal.example.com")
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			_, err := Detect(tc.Input, "/pwd", Detectors, allowlist)
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %v", err)
+			}
+			if tc.Err && !errors.Is(err, ErrHostNotAllowed) {
+				t.Fatalf("expected ErrHostNotAllowed, got: %s", err)
+			}
+		})
+	}
+
+	// Without an allowlist any host is allowed.
+	if _, err := Detect("gitlab.com/org/repo", "/pwd", Detectors); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestHostAllowlist(t *testing.T) {
+	old := HostAllowlist
+	defer func() { HostAllowlist = old }()
+	HostAllowlist = []string{"github.com"}
+
+	if _, err := Detect("github.com/hashicorp/foo", "/pwd", Detectors); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	_, err := Detect("gitlab.com/org/repo", "/pwd", Detectors)
+	var hostErr *HostNotAllowedError
+	if !errors.As(err, &hostErr) {
+		t.Fatalf("expected HostNotAllowedError, got: %v", err)
+	}
+	if hostErr.Host != "gitlab.com" {
+		t.Fatalf("bad host: %s", hostErr.Host)
+	}
+
+	// The per-call allowlist replaces the package-level one.
+	if _, err := Detect("gitlab.com/org/repo", "/pwd", Detectors, WithHostAllowlist("gitlab.com")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := Detect("gitlab.com/org/repo", "/pwd", Detectors, WithHostAllowlist()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
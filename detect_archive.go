@@ -0,0 +1,82 @@
+package getter
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ArchiveDetector implements Detector to detect http:// and https:// URLs
+// of archives, such as "https://example.com/module.tar.gz", and set their
+// "archive" parameter, as in "https://example.com/module.tar.gz?archive=tar.gz",
+// so that they are unpacked by the matching decompressor.
+//
+// The longest matching extension is used, so "module.tar.gz" is a
+// "tar.gz" archive rather than a "gz" one. Extensions are matched without
+// regard to case. URLs that already have an "archive" parameter, or that
+// are forced to a getter, are left alone.
+//
+// This detector isn't in the default Detectors since the Client already
+// matches the extensions of Decompressors when unpacking.
+type ArchiveDetector struct {
+	// Extensions are the archive extensions, without the leading ".", that
+	// are detected, such as "tar.gz". If this is empty, the keys of
+	// Decompressors are used.
+	Extensions []string
+}
+
+func (d *ArchiveDetector) Name() string {
+	return "archive"
+}
+
+// Detect never matches since only sources that are already URLs are
+// detected, by DetectURL.
+func (d *ArchiveDetector) Detect(string, string) (string, bool, error) {
+	return "", false, nil
+}
+
+// DetectURL implements URLDetector to set the "archive" parameter of
+// archive URLs.
+func (d *ArchiveDetector) DetectURL(src string) (string, bool, error) {
+	if force, _ := getForcedGetter(src); force != "" {
+		return "", false, nil
+	}
+
+	u, err := url.Parse(src)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false, nil
+	}
+	if u.Query().Get("archive") != "" {
+		return "", false, nil
+	}
+
+	ext := d.match(u.Path)
+	if ext == "" {
+		return "", false, nil
+	}
+
+	q := u.Query()
+	q.Set("archive", ext)
+	u.RawQuery = q.Encode()
+	return u.String(), true, nil
+}
+
+// match returns the longest archive extension that path ends with, or an
+// empty string if there is none.
+func (d *ArchiveDetector) match(path string) string {
+	exts := d.Extensions
+	if len(exts) == 0 {
+		for ext := range Decompressors {
+			exts = append(exts, ext)
+		}
+	}
+
+	path = strings.ToLower(path)
+	var match string
+	for _, ext := range exts {
+		ext = strings.ToLower(ext)
+		if strings.HasSuffix(path, "."+ext) && len(ext) > len(match) {
+			match = ext
+		}
+	}
+	return match
+}
@@ -0,0 +1,76 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestArchiveDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"https://example.com/foo.tar.gz", "https://example.com/foo.tar.gz?archive=tar.gz"},
+		{"https://example.com/foo.tar.bz2", "https://example.com/foo.tar.bz2?archive=tar.bz2"},
+		{"https://example.com/foo.tar.xz", "https://example.com/foo.tar.xz?archive=tar.xz"},
+		{"https://example.com/foo.tgz", "https://example.com/foo.tgz?archive=tgz"},
+		{"https://example.com/foo.tbz2", "https://example.com/foo.tbz2?archive=tbz2"},
+		{"https://example.com/foo.txz", "https://example.com/foo.txz?archive=txz"},
+		{"https://example.com/foo.gz", "https://example.com/foo.gz?archive=gz"},
+		{"https://example.com/foo.bz2", "https://example.com/foo.bz2?archive=bz2"},
+		{"https://example.com/foo.xz", "https://example.com/foo.xz?archive=xz"},
+		{"http://example.com/foo.zip", "http://example.com/foo.zip?archive=zip"},
+		{"https://example.com/FOO.ZIP", "https://example.com/FOO.ZIP?archive=zip"},
+		{
+			"https://example.com/foo.tar.gz//sub?checksum=md5:abc",
+			"https://example.com/foo.tar.gz//sub?archive=tar.gz&checksum=md5%3Aabc",
+		},
+		{"https://example.com/foo.tar.gz#frag", "https://example.com/foo.tar.gz?archive=tar.gz#frag"},
+		{"https://example.com/foo.zip?x=1#frag", "https://example.com/foo.zip?archive=zip&x=1#frag"},
+
+		// Not archives, or already handled
+		{"https://example.com/foo.txt", "https://example.com/foo.txt"},
+		{"https://example.com/foo.tar", "https://example.com/foo.tar"},
+		{"https://example.com/zip", "https://example.com/zip"},
+		{"https://example.com/foo.zip?archive=false", "https://example.com/foo.zip?archive=false"},
+		{"s3::https://s3.amazonaws.com/bucket/foo.zip", "s3::https://s3.amazonaws.com/bucket/foo.zip"},
+		{"file:///foo.zip", "file:///foo.zip"},
+	}
+
+	ds := []Detector{new(ArchiveDetector)}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", ds)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v\nexpected: %#v", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestArchiveDetector_extensions(t *testing.T) {
+	ds := []Detector{&ArchiveDetector{Extensions: []string{"zip", "tar.zst"}}}
+
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"https://example.com/foo.tar.zst", "https://example.com/foo.tar.zst?archive=tar.zst"},
+		{"https://example.com/foo.zip", "https://example.com/foo.zip?archive=zip"},
+		{"https://example.com/foo.tar.gz", "https://example.com/foo.tar.gz"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", ds)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v\nexpected: %#v", output, tc.Output)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package getter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -12,19 +13,35 @@ import (
 // them into URLs that the Git or Hg Getter can understand.
 type BitBucketDetector struct{}
 
-func (d *BitBucketDetector) Detect(src, _ string) (string, bool, error) {
+func (d *BitBucketDetector) Name() string {
+	return "bitbucket"
+}
+
+// NeedsNetwork implements NetworkDetector since the detector looks up
+// repositories with an API.
+func (d *BitBucketDetector) NeedsNetwork() bool {
+	return true
+}
+
+func (d *BitBucketDetector) Detect(src, pwd string) (string, bool, error) {
+	return d.DetectContext(context.Background(), src, pwd)
+}
+
+// DetectContext implements ContextDetector so that the BitBucket API
+// lookup can be canceled.
+func (d *BitBucketDetector) DetectContext(ctx context.Context, src, _ string) (string, bool, error) {
 	if len(src) == 0 {
 		return "", false, nil
 	}
 
 	if strings.HasPrefix(src, "bitbucket.org/") {
-		return d.detectHTTP(src)
+		return d.detectHTTP(ctx, src)
 	}
 
 	return "", false, nil
 }
 
-func (d *BitBucketDetector) detectHTTP(src string) (string, bool, error) {
+func (d *BitBucketDetector) detectHTTP(ctx context.Context, src string) (string, bool, error) {
 	u, err := url.Parse("https://" + src)
 	if err != nil {
 		return "", true, fmt.Errorf("error parsing BitBucket URL: %s", err)
@@ -36,10 +53,15 @@ func (d *BitBucketDetector) detectHTTP(src string) (string, bool, error) {
 		SCM string `json:"scm"`
 	}
 	infoUrl := "https://api.bitbucket.org/2.0/repositories" + u.Path
-	resp, err := http.Get(infoUrl)
+	req, err := http.NewRequestWithContext(ctx, "GET", infoUrl, nil)
+	if err != nil {
+		return "", true, fmt.Errorf("error looking up BitBucket URL: %s", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", true, fmt.Errorf("error looking up BitBucket URL: %s", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode == 403 {
 		// A private repo
 		return "", true, fmt.Errorf(
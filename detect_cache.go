@@ -0,0 +1,100 @@
+package getter
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultDetectCacheSize is the number of results kept by a
+// CachingDetector whose Size is zero.
+const defaultDetectCacheSize = 256
+
+// CachingDetector wraps a Detector, such as one that makes network
+// requests, to remember its results for each source and pwd so that
+// detecting the same source again doesn't call it again.
+//
+// Only matches and declines are remembered. Errors, such as from a
+// transient network failure, are not, so the source is detected again on
+// the next call.
+//
+// The Name, ForceTokens and NeedsNetwork of the wrapped Detector are
+// passed through. Other optional interfaces aren't.
+type CachingDetector struct {
+	// Detector is the wrapped detector.
+	Detector Detector
+
+	// Size is the most results that are kept. Once it is reached, the
+	// oldest result is dropped. If this is zero, 256 results are kept.
+	Size int
+
+	lock  sync.Mutex
+	cache map[detectCacheKey]detectCacheEntry
+	order []detectCacheKey
+}
+
+// detectCacheKey is the input to a detector.
+type detectCacheKey struct {
+	src, pwd string
+}
+
+// detectCacheEntry is the result of a detector that matched or declined.
+type detectCacheEntry struct {
+	result string
+	ok     bool
+}
+
+func (d *CachingDetector) Name() string {
+	return detectorName(d.Detector)
+}
+
+func (d *CachingDetector) ForceTokens() []string {
+	if fd, ok := d.Detector.(ForceTokenDetector); ok {
+		return fd.ForceTokens()
+	}
+	return nil
+}
+
+func (d *CachingDetector) NeedsNetwork() bool {
+	nd, ok := d.Detector.(NetworkDetector)
+	return ok && nd.NeedsNetwork()
+}
+
+func (d *CachingDetector) Detect(src, pwd string) (string, bool, error) {
+	return d.DetectContext(context.Background(), src, pwd)
+}
+
+func (d *CachingDetector) DetectContext(ctx context.Context, src, pwd string) (string, bool, error) {
+	key := detectCacheKey{src: src, pwd: pwd}
+
+	d.lock.Lock()
+	entry, found := d.cache[key]
+	d.lock.Unlock()
+	if found {
+		return entry.result, entry.ok, nil
+	}
+
+	result, ok, err := runDetector(ctx, d.Detector, src, pwd)
+	if err != nil {
+		return result, ok, err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.cache == nil {
+		d.cache = make(map[detectCacheKey]detectCacheEntry)
+	}
+	if _, found := d.cache[key]; !found {
+		size := d.Size
+		if size <= 0 {
+			size = defaultDetectCacheSize
+		}
+		for len(d.order) >= size {
+			delete(d.cache, d.order[0])
+			d.order = d.order[1:]
+		}
+		d.order = append(d.order, key)
+	}
+	d.cache[key] = detectCacheEntry{result: result, ok: ok}
+
+	return result, ok, nil
+}
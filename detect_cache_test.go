@@ -0,0 +1,130 @@
+package getter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// flakyTestDetector is a NetworkDetector that counts its calls and fails
+// while fail is set.
+type flakyTestDetector struct {
+	lock  sync.Mutex
+	calls int
+	fail  bool
+}
+
+func (d *flakyTestDetector) NeedsNetwork() bool {
+	return true
+}
+
+func (d *flakyTestDetector) Detect(src, _ string) (string, bool, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.calls++
+	if d.fail {
+		return "", true, errors.New("connection reset")
+	}
+	if src == "decline" {
+		return "", false, nil
+	}
+	return "https://example.com/" + src, true, nil
+}
+
+func TestCachingDetector(t *testing.T) {
+	flaky := new(flakyTestDetector)
+	d := &CachingDetector{Detector: flaky}
+
+	for i := 0; i < 3; i++ {
+		output, ok, err := d.Detect("foo", "/pwd")
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !ok || output != "https://example.com/foo" {
+			t.Fatalf("bad: %s, %t", output, ok)
+		}
+
+		if _, ok, err := d.Detect("decline", "/pwd"); ok || err != nil {
+			t.Fatalf("expected decline, got: %t, %v", ok, err)
+		}
+	}
+	if flaky.calls != 2 {
+		t.Fatalf("expected 2 calls, got: %d", flaky.calls)
+	}
+
+	// A different pwd is a different input.
+	if _, _, err := d.Detect("foo", "/other"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if flaky.calls != 3 {
+		t.Fatalf("expected 3 calls, got: %d", flaky.calls)
+	}
+
+	if !d.NeedsNetwork() {
+		t.Fatal("expected NeedsNetwork to be passed through")
+	}
+}
+
+func TestCachingDetector_errors(t *testing.T) {
+	flaky := &flakyTestDetector{fail: true}
+	d := &CachingDetector{Detector: flaky}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := d.Detect("foo", "/pwd"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if flaky.calls != 2 {
+		t.Fatalf("expected errors not to be cached, got %d calls", flaky.calls)
+	}
+
+	// Once the failure clears, the result is cached.
+	flaky.fail = false
+	for i := 0; i < 2; i++ {
+		if _, _, err := d.Detect("foo", "/pwd"); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if flaky.calls != 3 {
+		t.Fatalf("expected 3 calls, got: %d", flaky.calls)
+	}
+}
+
+func TestCachingDetector_size(t *testing.T) {
+	flaky := new(flakyTestDetector)
+	d := &CachingDetector{Detector: flaky, Size: 2}
+
+	for _, src := range []string{"a", "b", "c", "c", "b", "a"} {
+		if _, _, err := d.Detect(src, "/pwd"); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	// "a" was dropped to make room for "c", so it was detected again.
+	if flaky.calls != 4 {
+		t.Fatalf("expected 4 calls, got: %d", flaky.calls)
+	}
+}
+
+func TestCachingDetector_detect(t *testing.T) {
+	resolver := new(countingRepoIDResolver)
+	ds := []Detector{
+		&CachingDetector{Detector: &GitHubIDDetector{Resolver: resolver}},
+		new(GitHubDetector),
+	}
+
+	for i := 0; i < 3; i++ {
+		output, err := DetectContext(context.Background(), "ghid::12345", "/pwd", ds)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if output != "git::https://github.com/hashicorp/go-getter.git" {
+			t.Fatalf("bad output: %s", output)
+		}
+	}
+	if resolver.calls != 1 {
+		t.Fatalf("expected 1 lookup, got: %d", resolver.calls)
+	}
+}
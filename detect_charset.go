@@ -0,0 +1,61 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// knownCharsets is the set of names accepted for the "charset" query
+// parameter on file and HTTP sources under strict detection.
+var knownCharsets = map[string]bool{
+	"us-ascii":     true,
+	"utf-8":        true,
+	"utf-16":       true,
+	"utf-16be":     true,
+	"utf-16le":     true,
+	"utf-32":       true,
+	"utf-32be":     true,
+	"utf-32le":     true,
+	"iso-8859-1":   true,
+	"iso-8859-15":  true,
+	"windows-1252": true,
+	"shift_jis":    true,
+	"euc-jp":       true,
+	"euc-kr":       true,
+	"gb18030":      true,
+	"big5":         true,
+}
+
+// validateCharset checks the "charset" query parameter of file and HTTP
+// sources, if any, against knownCharsets. The parameter itself is passed
+// through untouched for the getter to use.
+func validateCharset(r *DetectResult) error {
+	if r.Query == "" {
+		return nil
+	}
+
+	u, err := url.Parse(r.Source)
+	if err != nil {
+		return nil
+	}
+	switch u.Scheme {
+	case "file", "http", "https":
+	default:
+		return nil
+	}
+
+	q, err := url.ParseQuery(r.Query)
+	if err != nil {
+		return nil
+	}
+	if _, ok := q["charset"]; !ok {
+		return nil
+	}
+
+	charset := q.Get("charset")
+	if !knownCharsets[strings.ToLower(charset)] {
+		return fmt.Errorf("unknown charset %q in source: %s", charset, r.String())
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestDetect_charset(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Strict bool
+		Output string
+		Err    bool
+	}{
+		{"./foo.txt?charset=utf-16", true, "file:///pwd/foo.txt?charset=utf-16", false},
+		{"https://example.com/foo.txt?charset=UTF-8", true, "https://example.com/foo.txt?charset=UTF-8", false},
+		{"https://example.com/foo.txt?charset=klingon", false, "https://example.com/foo.txt?charset=klingon", false},
+		{"https://example.com/foo.txt?charset=klingon", true, "", true},
+		{"./foo.txt?charset=klingon", true, "", true},
+		{"git::ssh://git@example.com/foo.git?charset=klingon", true, "git::ssh://git@example.com/foo.git?charset=klingon", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			var opts []DetectOption
+			if tc.Strict {
+				opts = append(opts, WithStrictDetect())
+			}
+
+			output, err := Detect(tc.Input, "/pwd", Detectors, opts...)
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
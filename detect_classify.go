@@ -0,0 +1,68 @@
+package getter
+
+import (
+	"net/url"
+)
+
+// SourceClass is the broad type of a source, as determined by detection.
+type SourceClass uint
+
+const (
+	// SourceClassUnknown is a source that isn't any of the other classes.
+	SourceClassUnknown SourceClass = iota
+
+	// SourceClassGit is a Git repository.
+	SourceClassGit
+
+	// SourceClassHg is a Mercurial repository.
+	SourceClassHg
+
+	// SourceClassFile is a local file or directory.
+	SourceClassFile
+
+	// SourceClassS3 is an Amazon S3 object or prefix.
+	SourceClassS3
+
+	// SourceClassGCS is a Google Cloud Storage object or prefix.
+	SourceClassGCS
+
+	// SourceClassHTTP is a plain HTTP or HTTPS URL.
+	SourceClassHTTP
+
+	// SourceClassOCI is an OCI registry artifact.
+	SourceClassOCI
+)
+
+// sourceClasses maps force tokens and URL schemes to their SourceClass.
+var sourceClasses = map[string]SourceClass{
+	"git":   SourceClassGit,
+	"hg":    SourceClassHg,
+	"file":  SourceClassFile,
+	"s3":    SourceClassS3,
+	"gcs":   SourceClassGCS,
+	"http":  SourceClassHTTP,
+	"https": SourceClassHTTP,
+	"oci":   SourceClassOCI,
+}
+
+// ClassifySource detects src using the registered detectors and returns
+// the class of the result. The class is taken from the force token if there
+// is one and from the URL scheme otherwise.
+func ClassifySource(src, pwd string) (SourceClass, error) {
+	src, err := Detect(src, pwd, registeredDetectors())
+	if err != nil {
+		return SourceClassUnknown, err
+	}
+
+	force, src := getForcedGetter(src)
+	if force != "" {
+		return sourceClasses[force], nil
+	}
+
+	u, err := url.Parse(src)
+	if err != nil {
+		return SourceClassUnknown, err
+	}
+
+	return sourceClasses[u.Scheme], nil
+}
@@ -0,0 +1,36 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestClassifySource(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output SourceClass
+	}{
+		{"./foo", SourceClassFile},
+		{"github.com/hashicorp/foo", SourceClassGit},
+		{"git@github.com:hashicorp/foo.git", SourceClassGit},
+		{"git::https://example.com/foo.git", SourceClassGit},
+		{"hg@example.com:foo", SourceClassHg},
+		{"bucket.s3.amazonaws.com/foo", SourceClassS3},
+		{"www.googleapis.com/storage/v1/bucket/foo", SourceClassGCS},
+		{"https://example.com/foo.zip", SourceClassHTTP},
+		{"http://example.com/foo.zip", SourceClassHTTP},
+		{"oci://ghcr.io/org/module", SourceClassOCI},
+		{"ftp://example.com/foo", SourceClassUnknown},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			class, err := ClassifySource(tc.Input, "/pwd")
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if class != tc.Output {
+				t.Fatalf("bad class: %d, expected: %d", class, tc.Output)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// codeCommitHostPattern matches the Git host of AWS CodeCommit in a region.
+var codeCommitHostPattern = regexp.MustCompile(`^git-codecommit\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+
+// codeCommitRegionPattern matches an AWS region such as "us-east-1".
+var codeCommitRegionPattern = regexp.MustCompile(`^[a-z]{2}(?:-[a-z]+)+-[0-9]+$`)
+
+// codeCommitRepoPattern matches a valid CodeCommit repository name.
+var codeCommitRepoPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+// CodeCommitDetector implements Detector to detect AWS CodeCommit
+// repositories and turn them into URLs that the Git Getter can
+// understand, such as
+// "git::https://git-codecommit.us-east-1.amazonaws.com/v1/repos/name".
+//
+// Both the "codecommit::us-east-1://name" form used by git-remote-codecommit
+// and HTTPS URLs such as
+// "https://git-codecommit.us-east-1.amazonaws.com/v1/repos/name" are
+// detected. Named AWS profiles, as in "codecommit::us-east-1://profile@name",
+// aren't supported since the HTTPS URL can't carry them.
+type CodeCommitDetector struct{}
+
+func (d *CodeCommitDetector) Name() string {
+	return "codecommit"
+}
+
+func (d *CodeCommitDetector) ForceTokens() []string {
+	return []string{"codecommit"}
+}
+
+func (d *CodeCommitDetector) Detect(src, _ string) (string, bool, error) {
+	if len(src) == 0 {
+		return "", false, nil
+	}
+
+	if force, rest := getForcedGetter(src); force == "codecommit" {
+		return d.detectRegion(rest)
+	}
+
+	if strings.HasPrefix(src, "git-codecommit.") {
+		return d.detectHTTP("https://" + src)
+	}
+
+	return "", false, nil
+}
+
+// DetectURL implements URLDetector since both the forms detected are valid
+// URLs.
+func (d *CodeCommitDetector) DetectURL(src string) (string, bool, error) {
+	force, rest := getForcedGetter(src)
+	switch force {
+	case "codecommit":
+		return d.detectRegion(rest)
+	case "", "git":
+		if strings.HasPrefix(rest, "https://git-codecommit.") {
+			return d.detectHTTP(rest)
+		}
+	}
+
+	return "", false, nil
+}
+
+// detectRegion detects the "region://name" form.
+func (d *CodeCommitDetector) detectRegion(src string) (string, bool, error) {
+	src, query := splitQuery(src)
+
+	idx := strings.Index(src, "://")
+	if idx == -1 {
+		return "", true, fmt.Errorf(
+			"CodeCommit sources should be codecommit::region://name")
+	}
+	region, name := src[:idx], src[idx+len("://"):]
+
+	if strings.Contains(name, "@") {
+		return "", true, fmt.Errorf(
+			"CodeCommit profiles are not supported: %s", src)
+	}
+
+	return d.fmtURL(region, name, query)
+}
+
+// detectHTTP detects the HTTPS URL form.
+func (d *CodeCommitDetector) detectHTTP(src string) (string, bool, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return "", true, fmt.Errorf("error parsing CodeCommit URL: %s", err)
+	}
+
+	matched := codeCommitHostPattern.FindStringSubmatch(u.Host)
+	if matched == nil {
+		return "", false, nil
+	}
+
+	name := strings.TrimPrefix(u.Path, "/v1/repos/")
+	if name == u.Path {
+		return "", true, fmt.Errorf(
+			"CodeCommit URLs should be https://git-codecommit.region.amazonaws.com/v1/repos/name")
+	}
+
+	return d.fmtURL(matched[1], name, u.RawQuery)
+}
+
+func (d *CodeCommitDetector) fmtURL(region, name, query string) (string, bool, error) {
+	if !codeCommitRegionPattern.MatchString(region) {
+		return "", true, fmt.Errorf("invalid AWS region %q", region)
+	}
+	if !codeCommitRepoPattern.MatchString(name) {
+		return "", true, fmt.Errorf("invalid CodeCommit repository %q", name)
+	}
+
+	host := "git-codecommit." + region + ".amazonaws.com"
+	if strings.HasPrefix(region, "cn-") {
+		host += ".cn"
+	}
+
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     "/v1/repos/" + name,
+		RawQuery: query,
+	}
+
+	return "git::" + u.String(), true, nil
+}
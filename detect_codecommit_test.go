@@ -0,0 +1,68 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestCodeCommitDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"codecommit::us-east-1://my-repo",
+			"git::https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo",
+		},
+		{
+			"codecommit::eu-west-2://my-repo//modules/vpc?ref=v1",
+			"git::https://git-codecommit.eu-west-2.amazonaws.com/v1/repos/my-repo//modules/vpc?ref=v1",
+		},
+		{
+			"codecommit::cn-north-1://my-repo",
+			"git::https://git-codecommit.cn-north-1.amazonaws.com.cn/v1/repos/my-repo",
+		},
+		{
+			"https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo",
+			"git::https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo",
+		},
+		{
+			"git::https://git-codecommit.ap-southeast-2.amazonaws.com/v1/repos/my-repo?ref=v1",
+			"git::https://git-codecommit.ap-southeast-2.amazonaws.com/v1/repos/my-repo?ref=v1",
+		},
+		{
+			"git-codecommit.us-gov-west-1.amazonaws.com/v1/repos/my.repo//sub",
+			"git::https://git-codecommit.us-gov-west-1.amazonaws.com/v1/repos/my.repo//sub",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v\nexpected: %#v", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestCodeCommitDetector_bad(t *testing.T) {
+	cases := []string{
+		"codecommit::my-repo",
+		"codecommit::us-east-1://",
+		"codecommit::us-east-1://profile@my-repo",
+		"codecommit::not_a_region://my-repo",
+		"codecommit::us-east-1://my/repo",
+		"https://git-codecommit.us-east-1.amazonaws.com/my-repo",
+	}
+
+	for _, input := range cases {
+		t.Run(input, func(t *testing.T) {
+			if _, err := Detect(input, "/pwd", Detectors); err == nil {
+				t.Fatal("should error")
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DaggerDetector implements Detector to detect Dagger module references,
+// such as "dagger::github.com/org/repo/path@v1.0.0" or
+// "dagger://github.com/org/repo/path@v1.0.0", and turn them into forced
+// URLs such as "git::https://github.com/org/repo//path?ref=v1.0.0".
+//
+// The first three segments are the host and repository. Any more segments
+// are the module's path in the repository, which becomes the subdir. A
+// version after "@" becomes the "ref" parameter.
+//
+// Only sources forced with "dagger::", or using the "dagger://" scheme, are
+// detected. This detector isn't in the default Detectors.
+type DaggerDetector struct {
+	// Force is the forced getter of detected sources. If this is empty,
+	// "git" is used.
+	Force string
+
+	// Scheme is the scheme of detected sources. If this is empty, "https"
+	// is used.
+	Scheme string
+
+	// Hosts maps hosts in module references to the hosts that they are
+	// fetched from, such as a mirror. Hosts that aren't in it are used
+	// as-is.
+	Hosts map[string]string
+}
+
+func (d *DaggerDetector) Name() string {
+	return "dagger"
+}
+
+func (d *DaggerDetector) ForceTokens() []string {
+	return []string{"dagger"}
+}
+
+func (d *DaggerDetector) Detect(src, _ string) (string, bool, error) {
+	force, src := getForcedGetter(src)
+	if force != "dagger" {
+		return "", false, nil
+	}
+
+	src, query := splitQuery(src)
+
+	var ref string
+	if idx := strings.LastIndex(src, "@"); idx > -1 {
+		src, ref = src[:idx], src[idx+1:]
+		if ref == "" {
+			return "", true, fmt.Errorf("empty version in Dagger module: %s", src)
+		}
+		if q, err := url.ParseQuery(query); err == nil && q.Get("ref") != "" {
+			return "", true, fmt.Errorf(
+				"ref is set both with @ and the ref parameter: %s", src)
+		}
+	}
+
+	parts := strings.Split(strings.Trim(src, "/"), "/")
+	if len(parts) < 3 {
+		return "", true, fmt.Errorf(
+			"Dagger modules should be dagger::host/owner/repo[/path][@version]")
+	}
+	for _, part := range parts {
+		if part == "" {
+			return "", true, fmt.Errorf("invalid Dagger module %q", src)
+		}
+	}
+
+	host := parts[0]
+	if mapped, ok := d.Hosts[host]; ok {
+		host = mapped
+	}
+
+	u := &url.URL{
+		Scheme: d.scheme(),
+		Host:   host,
+		Path:   "/" + strings.Join(parts[1:3], "/"),
+	}
+	result := d.force() + "::" + u.String()
+	if len(parts) > 3 {
+		result += "//" + strings.Join(parts[3:], "/")
+	}
+
+	if ref != "" {
+		if query != "" {
+			query += "&"
+		}
+		query += "ref=" + url.QueryEscape(ref)
+	}
+	if query != "" {
+		result += "?" + query
+	}
+
+	return result, true, nil
+}
+
+func (d *DaggerDetector) force() string {
+	if d.Force != "" {
+		return d.Force
+	}
+	return "git"
+}
+
+func (d *DaggerDetector) scheme() string {
+	if d.Scheme != "" {
+		return d.Scheme
+	}
+	return "https"
+}
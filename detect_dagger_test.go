@@ -0,0 +1,90 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestDaggerDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		// Forced
+		{
+			"dagger::github.com/org/repo",
+			"git::https://github.com/org/repo",
+		},
+		{
+			"dagger::github.com/org/repo/modules/lint@v1.2.0",
+			"git::https://github.com/org/repo//modules/lint?ref=v1.2.0",
+		},
+		{
+			"dagger::github.com/org/repo?depth=1",
+			"git::https://github.com/org/repo?depth=1",
+		},
+
+		// Scheme
+		{
+			"dagger://github.com/org/repo/modules/lint@v1.2.0",
+			"git::https://github.com/org/repo//modules/lint?ref=v1.2.0",
+		},
+		{
+			"dagger://github.com/org/repo?ref=main",
+			"git::https://github.com/org/repo?ref=main",
+		},
+		{
+			"dagger://github.com/org/repo//modules/lint?ref=main",
+			"git::https://github.com/org/repo//modules/lint?ref=main",
+		},
+	}
+
+	ds := []Detector{new(DaggerDetector), new(FileDetector)}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", ds)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestDaggerDetector_configured(t *testing.T) {
+	d := &DaggerDetector{
+		Force:  "hg",
+		Scheme: "ssh",
+		Hosts:  map[string]string{"github.com": "mirror.example.com"},
+	}
+	output, err := Detect("dagger://github.com/org/repo/sub@v1", "/pwd", []Detector{d})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if expected := "hg::ssh://mirror.example.com/org/repo//sub?ref=v1"; output != expected {
+		t.Fatalf("bad: %s\nexpected: %s", output, expected)
+	}
+}
+
+func TestDaggerDetector_bad(t *testing.T) {
+	cases := []string{
+		"dagger::github.com/org",
+		"dagger::github.com/org/repo@",
+		"dagger::github.com/org/repo@v1?ref=v2",
+	}
+
+	ds := []Detector{new(DaggerDetector)}
+	for _, input := range cases {
+		t.Run(input, func(t *testing.T) {
+			if _, err := Detect(input, "/pwd", ds); err == nil {
+				t.Fatal("should error")
+			}
+		})
+	}
+
+	// Sources that aren't forced aren't Dagger modules.
+	if _, ok, err := new(DaggerDetector).Detect("github.com/org/repo", "/pwd"); ok || err != nil {
+		t.Fatalf("should not match: %t, %v", ok, err)
+	}
+}
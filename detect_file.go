@@ -2,19 +2,89 @@ package getter
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"os/user"
 	"path/filepath"
 	"runtime"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
 )
 
 // FileDetector implements Detector to detect file paths.
-type FileDetector struct{}
+type FileDetector struct {
+	// ResolveSymlinks, if true, resolves any symlinks in the path before
+	// building the file URL so that the URL holds the canonical path. The
+	// path must then exist.
+	ResolveSymlinks bool
+
+	// DisableTildeExpansion, if true, turns off the expansion of a leading
+	// "~" or "~user" path segment to the home directory, for paths that
+	// really start with a directory named "~".
+	DisableTildeExpansion bool
+
+	// RootMarker, if set, is the name of a file or directory (such as
+	// ".git") that marks the root of a monorepo. Relative paths are then
+	// resolved against the nearest ancestor of pwd, including pwd itself,
+	// that contains it. If no ancestor does, pwd is used.
+	RootMarker string
+
+	// PreserveTrailingSlash, if true, keeps a trailing slash on the path,
+	// which some getters take to mean the contents of the directory rather
+	// than the directory itself. By default the path is cleaned.
+	PreserveTrailingSlash bool
+
+	// ConfinementRoot, if set, is a directory that detected paths must
+	// stay within. Detection fails for a path, such as "../../etc/passwd",
+	// that resolves outside of it. Symlinks in both the path and the root
+	// are resolved before they are compared, so a symlink can't be used to
+	// escape the root either.
+	ConfinementRoot string
+
+	// BaseDir, if set, is an absolute directory that detected paths are
+	// made relative to, as in "file://./modules/net" or
+	// "file://../shared/net", so that they don't depend on where the
+	// source tree is, such as for reproducible lock files. Such URLs
+	// aren't valid file URLs under RFC 8089 and must be resolved against
+	// BaseDir again before they are fetched. Paths that can't be made
+	// relative, such as ones on another Windows drive, stay absolute.
+	BaseDir string
+}
+
+func (d *FileDetector) Name() string {
+	return "file"
+}
 
 func (d *FileDetector) Detect(src, pwd string) (string, bool, error) {
 	if len(src) == 0 {
 		return "", false, nil
 	}
 
+	// Only the path is a file path. The query is put back as-is, so that
+	// a backslash in a parameter isn't taken for a separator.
+	src, query := splitQuery(src)
+	trailingSlash := strings.HasSuffix(src, "/") ||
+		strings.HasSuffix(src, string(filepath.Separator))
+
+	if !d.DisableTildeExpansion {
+		var err error
+		src, err = expandTilde(src)
+		if err != nil {
+			return "", true, err
+		}
+	}
+
+	// On Windows, a path such as "C:foo" has a drive but is relative to
+	// the current directory on that drive.
+	if vol := filepath.VolumeName(src); vol != "" && !filepath.IsAbs(src) {
+		var err error
+		src, err = resolveDriveRelative(src, vol, pwd)
+		if err != nil {
+			return "", true, err
+		}
+	}
+
 	if !filepath.IsAbs(src) {
 		if pwd == "" {
 			return "", true, fmt.Errorf(
@@ -46,16 +116,249 @@ func (d *FileDetector) Detect(src, pwd string) (string, bool, error) {
 			}
 		}
 
+		if d.RootMarker != "" {
+			root, err := findRootMarker(pwd, d.RootMarker)
+			if err != nil {
+				return "", true, err
+			}
+			if root != "" {
+				pwd = root
+			}
+		}
+
 		src = filepath.Join(pwd, src)
 	}
 
-	return fmtFileURL(src), true, nil
+	if d.ResolveSymlinks {
+		resolved, err := filepath.EvalSymlinks(src)
+		if err != nil {
+			return "", true, fmt.Errorf(
+				"error resolving symlinks in %q: %s", src, err)
+		}
+		src = resolved
+	}
+
+	if d.ConfinementRoot != "" {
+		if err := checkConfined(src, d.ConfinementRoot); err != nil {
+			return "", true, err
+		}
+	}
+
+	if d.PreserveTrailingSlash && trailingSlash &&
+		!strings.HasSuffix(src, string(filepath.Separator)) {
+		src += string(filepath.Separator)
+	}
+
+	var result string
+	if d.BaseDir != "" {
+		if !filepath.IsAbs(d.BaseDir) {
+			return "", true, fmt.Errorf(
+				"base directory must be absolute: %s", d.BaseDir)
+		}
+		if rel, err := filepath.Rel(d.BaseDir, src); err == nil {
+			result = "file://" + cleanPreservingRelativePrefix(rel)
+			if d.PreserveTrailingSlash && trailingSlash && !strings.HasSuffix(result, "/") {
+				result += "/"
+			}
+		}
+	}
+	if result == "" {
+		result = fmtFileURL(src)
+	}
+	if query != "" {
+		result += "?" + query
+	}
+	return result, true, nil
+}
+
+// cleanPreservingRelativePrefix cleans the relative path rel and returns
+// it with "/" separators and an explicit "./" prefix unless it starts with
+// "..", so that it reads as relative, as in "./modules/net".
+func cleanPreservingRelativePrefix(rel string) string {
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return rel
+	}
+	return "./" + rel
+}
+
+// resolveDriveRelative resolves a Windows drive-relative path such as
+// "C:foo", where vol is its drive. If pwd is on the same drive, the path is
+// taken to be relative to pwd. Otherwise the current directory of the
+// process on that drive is used, as Windows itself does.
+func resolveDriveRelative(path, vol, pwd string) (string, error) {
+	if strings.EqualFold(filepath.VolumeName(pwd), vol) {
+		return filepath.Join(pwd, path[len(vol):]), nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("error resolving %q: %s", path, err)
+	}
+	return abs, nil
+}
+
+// checkConfined returns an error if path is not within root once symlinks
+// in both have been resolved.
+func checkConfined(path, root string) error {
+	resolvedRoot, err := evalExistingSymlinks(root)
+	if err != nil {
+		return fmt.Errorf("error resolving root %q: %s", root, err)
+	}
+	resolved, err := evalExistingSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("error resolving symlinks in %q: %s", path, err)
+	}
+
+	rel, err := filepath.Rel(resolvedRoot, resolved)
+	if err != nil || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path %q is outside of %q", path, root)
+	}
+	return nil
+}
+
+// evalExistingSymlinks returns the absolute form of path with symlinks
+// resolved. Since the path need not exist yet, only its longest existing
+// ancestor is resolved and the rest is joined back as-is.
+func evalExistingSymlinks(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var rest []string
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		rest = append([]string{filepath.Base(path)}, rest...)
+		path = parent
+	}
+}
+
+// findRootMarker walks up from dir and returns the first directory that
+// contains marker, or an empty string if there is none.
+func findRootMarker(dir, marker string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		_, err := os.Lstat(filepath.Join(dir, marker))
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// expandTilde expands a leading "~" path segment to the current user's
+// home directory and a leading "~name" segment to that user's home
+// directory. A "~" anywhere else is left alone.
+func expandTilde(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+
+	name, rest := path[1:], ""
+	if idx := strings.IndexAny(name, `/`+string(filepath.Separator)); idx > -1 {
+		name, rest = name[:idx], name[idx:]
+	}
+
+	var home string
+	if name == "" {
+		var err error
+		home, err = homedir.Dir()
+		if err != nil {
+			return "", fmt.Errorf("error expanding %q: %s", path, err)
+		}
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("error expanding %q: %s", path, err)
+		}
+		home = u.HomeDir
+	}
+
+	return filepath.Join(home, rest), nil
+}
+
+// escapeFileURL escapes the path of a file URL built from a raw file path,
+// such as "file:///my repo?ref=v1". The query is left as-is.
+func escapeFileURL(src string) string {
+	src, query := splitQuery(src)
+
+	u := &url.URL{Scheme: "file", Path: strings.TrimPrefix(src, "file://")}
+	result := u.String()
+	if query != "" {
+		result += "?" + query
+	}
+
+	return result
+}
+
+// normalizeFileURL turns a file URL without an authority, such as
+// "file:/path", or with the "localhost" authority, such as
+// "file://localhost/path", into the "file:///path" form that Git
+// requires. Other hosts are kept, since on Windows they name a UNC share.
+func normalizeFileURL(src string) string {
+	rest := src[len("file:"):]
+	switch {
+	case strings.HasPrefix(strings.ToLower(rest), "//localhost/"):
+		return "file://" + rest[len("//localhost"):]
+	case strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "//"):
+		return "file://" + rest
+	}
+	return src
 }
 
 func fmtFileURL(path string) string {
 	if runtime.GOOS == "windows" {
-		// Make sure we're using "/" on Windows. URLs are "/"-based.
+		// Make sure we're using "/" on Windows. URLs are "/"-based. Only
+		// the path is converted, since a backslash in the query is part
+		// of a parameter.
+		path, query := splitQuery(path)
 		path = filepath.ToSlash(path)
+		if query != "" {
+			path += "?" + query
+		}
+
+		// An extended-length UNC path such as \\?\UNC\server\share\path
+		// is the same as the plain UNC path \\server\share\path, and
+		// \\?\C:\path is the same as C:\path.
+		if strings.HasPrefix(path, "//?/UNC/") {
+			path = "//" + path[len("//?/UNC/"):]
+		} else if strings.HasPrefix(path, "//?/") {
+			path = path[len("//?/"):]
+		}
+
+		// A UNC path such as \\server\share\path has a volume name
+		// starting with two slashes. The server becomes the URL authority,
+		// as in file://server/share/path. Drive letter paths keep the
+		// file://C:/path form used by the url helper.
+		if vol := filepath.VolumeName(path); strings.HasPrefix(vol, "//") {
+			return fmt.Sprintf("file:%s", path)
+		}
+
 		return fmt.Sprintf("file://%s", path)
 	}
 
@@ -2,11 +2,15 @@ package getter
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/user"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/mitchellh/go-homedir"
 )
 
 type fileTest struct {
@@ -113,3 +117,274 @@ func TestFileDetector_noPwd(t *testing.T) {
 		}
 	}
 }
+
+func TestFileDetector_tilde(t *testing.T) {
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	current, err := user.Current()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cases := []struct {
+		in, out string
+	}{
+		{"~", fmtFileURL(home)},
+		{"~/modules/foo", fmtFileURL(filepath.Join(home, "modules/foo"))},
+		{"~" + current.Username + "/modules/foo", fmtFileURL(filepath.Join(current.HomeDir, "modules/foo"))},
+		{"./~/foo", fmtFileURL(filepath.Join("/pwd", "~/foo"))},
+	}
+
+	f := new(FileDetector)
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			out, ok, err := f.Detect(tc.in, "/pwd")
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if out != tc.out {
+				t.Fatalf("expected: %q\nbad output: %q", tc.out, out)
+			}
+		})
+	}
+
+	// Expansion can be turned off for directories named "~".
+	f = &FileDetector{DisableTildeExpansion: true}
+	out, _, err := f.Detect("~/foo", "/pwd")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if expected := fmtFileURL(filepath.Join("/pwd", "~/foo")); out != expected {
+		t.Fatalf("expected: %q\nbad output: %q", expected, out)
+	}
+}
+
+func TestFileDetector_rootMarker(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "go-getter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// root/
+	//   .git/
+	//   nested/
+	//     go.mod
+	//     a/b/
+	root := filepath.Join(tmpDir, "root")
+	nested := filepath.Join(root, "nested")
+	pwd := filepath.Join(nested, "a", "b")
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(pwd, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(nested, "go.mod"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		marker string
+		out    string
+	}{
+		{".git", fmtFileURL(filepath.Join(root, "modules", "foo"))},
+		{"go.mod", fmtFileURL(filepath.Join(nested, "modules", "foo"))},
+		{"nonexistent", fmtFileURL(filepath.Join(pwd, "modules", "foo"))},
+		{"", fmtFileURL(filepath.Join(pwd, "modules", "foo"))},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.marker, func(t *testing.T) {
+			f := &FileDetector{RootMarker: tc.marker}
+			out, ok, err := f.Detect("./modules/foo", pwd)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if out != tc.out {
+				t.Fatalf("expected: %q\nbad output: %q", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestFileDetector_trailingSlash(t *testing.T) {
+	cases := []struct {
+		in       string
+		preserve bool
+		out      string
+	}{
+		{"./foo/", false, "file:///pwd/foo"},
+		{"./foo/", true, "file:///pwd/foo/"},
+		{"./foo/?bar=baz", true, "file:///pwd/foo/?bar=baz"},
+		{"./foo", true, "file:///pwd/foo"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			f := &FileDetector{PreserveTrailingSlash: tc.preserve}
+			out, ok, err := f.Detect(tc.in, "/pwd")
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if out != tc.out {
+				t.Fatalf("expected: %q\nbad output: %q", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestFileDetector_baseDir(t *testing.T) {
+	base := filepath.Join(string(filepath.Separator), "src", "project")
+	if runtime.GOOS == "windows" {
+		base = `C:` + base
+	}
+
+	cases := []struct {
+		in       string
+		relative string
+	}{
+		{"./modules/net", "file://./modules/net"},
+		{"./modules/net?ref=v1", "file://./modules/net?ref=v1"},
+		{"../shared/net", "file://../shared/net"},
+		{"./modules/../modules/net/", "file://./modules/net"},
+		{".", "file://."},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			abs, ok, err := new(FileDetector).Detect(tc.in, base)
+			if err != nil || !ok {
+				t.Fatalf("err: %v, ok: %t", err, ok)
+			}
+			rel, ok, err := (&FileDetector{BaseDir: base}).Detect(tc.in, base)
+			if err != nil || !ok {
+				t.Fatalf("err: %v, ok: %t", err, ok)
+			}
+			if rel != tc.relative {
+				t.Fatalf("expected: %q\nbad output: %q", tc.relative, rel)
+			}
+			if abs == rel || !strings.HasPrefix(abs, "file://") || strings.Contains(abs, "/./") {
+				t.Fatalf("bad absolute output: %q", abs)
+			}
+		})
+	}
+
+	// The same path detected from another checkout gives the same URL.
+	other := filepath.Join(filepath.Dir(base), "checkout")
+	rel, _, err := (&FileDetector{BaseDir: other}).Detect("./modules/net", other)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if rel != "file://./modules/net" {
+		t.Fatalf("bad output: %q", rel)
+	}
+
+	if _, _, err := (&FileDetector{BaseDir: "relative"}).Detect("./foo", base); err == nil {
+		t.Fatal("expected error for relative base directory")
+	}
+}
+
+func TestFileDetector_confinementRoot(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "go-getter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// root/
+	//   modules/foo/
+	// outside/
+	root := filepath.Join(tmpDir, "root")
+	outside := filepath.Join(tmpDir, "outside")
+	if err := os.MkdirAll(filepath.Join(root, "modules", "foo"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		in  string
+		err bool
+	}{
+		{"./modules/foo", false},
+		{"./modules/foo?ref=v1", false},
+		{"./modules/missing", false},
+		{"./modules/../modules/foo", false},
+		{".", false},
+		{"..", true},
+		{"../outside", true},
+		{"../../../../../../etc/passwd", true},
+		{"./modules/../../outside", true},
+		{"../root-sibling", true},
+		{outside, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			f := &FileDetector{ConfinementRoot: root}
+			_, ok, err := f.Detect(tc.in, root)
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if (err != nil) != tc.err {
+				t.Fatalf("expected err: %t, got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestFileDetector_confinementRootSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges on Windows")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "go-getter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// root/
+	//   escape -> ../outside
+	// outside/
+	// link -> root
+	root := filepath.Join(tmpDir, "root")
+	outside := filepath.Join(tmpDir, "outside")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpDir, "link")
+	if err := os.Symlink(root, link); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &FileDetector{ConfinementRoot: root}
+	if _, _, err := f.Detect("./escape/foo", root); err == nil {
+		t.Fatal("expected error for a symlink out of the root")
+	}
+
+	// A root given through a symlink still contains its own paths.
+	f = &FileDetector{ConfinementRoot: link}
+	if _, _, err := f.Detect("./foo", root); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
@@ -68,3 +68,60 @@ func TestFileDetector_relativeSymlink(t *testing.T) {
 		t.Fatalf("bad:      %v", out)
 	}
 }
+
+func TestFileDetector_resolveSymlinks(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "go-getter")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	// We may have a symlinked tmp dir,
+	// e.g. OSX uses /var -> /private/var
+	tmpDir, err = filepath.EvalSymlinks(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.MkdirAll(filepath.Join(tmpDir, "real", "foo"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Symlink("real", filepath.Join(tmpDir, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// By default the symlink is left in the path.
+	f := new(FileDetector)
+	out, ok, err := f.Detect("./link/foo", tmpDir)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatal("not ok")
+	}
+	if out != "file://"+filepath.Join(tmpDir, "link/foo") {
+		t.Fatalf("bad: %v", out)
+	}
+
+	f = &FileDetector{ResolveSymlinks: true}
+	out, ok, err = f.Detect("./link/foo", tmpDir)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatal("not ok")
+	}
+	if out != "file://"+filepath.Join(tmpDir, "real/foo") {
+		t.Fatalf("bad: %v", out)
+	}
+
+	// The target must exist to be resolved.
+	_, _, err = f.Detect("./link/missing", tmpDir)
+	if err == nil {
+		t.Fatal("should error")
+	}
+}
@@ -0,0 +1,153 @@
+// +build windows
+
+package getter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileDetector_windowsVolumes(t *testing.T) {
+	cases := []struct {
+		in, pwd, out string
+	}{
+		{`\\server\share\repo`, `C:\pwd`, `file://server/share/repo`},
+		{`\\server\share\repo?ref=v1`, `C:\pwd`, `file://server/share/repo?ref=v1`},
+		{`\\?\UNC\server\share\repo`, `C:\pwd`, `file://server/share/repo`},
+		{`\\?\UNC\server\share\repo?ref=v1`, `C:\pwd`, `file://server/share/repo?ref=v1`},
+		{`\\?\C:\path\repo`, `C:\pwd`, `file://C:/path/repo`},
+		{`\\?\C:\path\repo?ref=v1`, `C:\pwd`, `file://C:/path/repo?ref=v1`},
+		{`C:\path\repo`, `C:\pwd`, `file://C:/path/repo`},
+		{`.\repo`, `C:\pwd`, `file://C:/pwd/repo`},
+		{`.\repo`, `\\server\share\pwd`, `file://server/share/pwd/repo`},
+	}
+
+	f := new(FileDetector)
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			out, ok, err := f.Detect(tc.in, tc.pwd)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if out != tc.out {
+				t.Fatalf("expected: %q\nbad output: %q", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestDetect_windowsUNC(t *testing.T) {
+	out, err := Detect(`git::\\server\share\repo`, `C:\pwd`, Detectors)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if expected := `git::file://server/share/repo`; out != expected {
+		t.Fatalf("expected: %q\nbad output: %q", expected, out)
+	}
+}
+
+func TestDetect_windowsExtendedLength(t *testing.T) {
+	cases := []struct {
+		in, out string
+	}{
+		{`git::\\?\C:\path\repo`, `git::file://C:/path/repo`},
+		{`git::\\?\C:\path\repo?ref=v1`, `git::file://C:/path/repo?ref=v1`},
+		{`git::\\?\UNC\server\share\repo`, `git::file://server/share/repo`},
+		{`\\?\C:\path\repo`, `file://C:/path/repo`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			out, err := Detect(tc.in, `C:\pwd`, Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if out != tc.out {
+				t.Fatalf("expected: %q\nbad output: %q", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestFileDetector_windowsDriveRelative(t *testing.T) {
+	cases := []struct {
+		in, pwd, out string
+	}{
+		{`C:repo`, `C:\pwd`, `file://C:/pwd/repo`},
+		{`c:repo\sub`, `C:\pwd`, `file://C:/pwd/repo/sub`},
+		{`C:repo?ref=v1`, `C:\pwd`, `file://C:/pwd/repo?ref=v1`},
+
+		// Drive-absolute paths are not relative to pwd.
+		{`C:\repo`, `C:\pwd`, `file://C:/repo`},
+		{`C:/repo`, `C:\pwd`, `file://C:/repo`},
+	}
+
+	f := new(FileDetector)
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			out, ok, err := f.Detect(tc.in, tc.pwd)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if out != tc.out {
+				t.Fatalf("expected: %q\nbad output: %q", tc.out, out)
+			}
+		})
+	}
+
+	// On another drive than pwd, the path is resolved against the current
+	// directory of the process on that drive.
+	out, _, err := f.Detect(`D:repo`, `C:\pwd`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.HasPrefix(out, "file://D:/") || !strings.HasSuffix(out, "/repo") {
+		t.Fatalf("bad output: %q", out)
+	}
+}
+
+func TestDetect_windowsDriveRelative(t *testing.T) {
+	out, err := Detect(`git::C:repo`, `C:\pwd`, Detectors)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if expected := `git::file://C:/pwd/repo`; out != expected {
+		t.Fatalf("expected: %q\nbad output: %q", expected, out)
+	}
+}
+
+func TestFileDetector_windowsQueryBackslash(t *testing.T) {
+	cases := []struct {
+		in, pwd, out string
+	}{
+		{`C:\path\repo?ref=feature\x`, `C:\pwd`, `file://C:/path/repo?ref=feature\x`},
+		{`.\repo\sub?ref=a\..\b`, `C:\pwd`, `file://C:/pwd/repo/sub?ref=a\..\b`},
+		{`\\server\share\repo?ref=a\b`, `C:\pwd`, `file://server/share/repo?ref=a\b`},
+	}
+
+	f := new(FileDetector)
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			out, ok, err := f.Detect(tc.in, tc.pwd)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if out != tc.out {
+				t.Fatalf("expected: %q\nbad output: %q", tc.out, out)
+			}
+		})
+	}
+
+	if out := fmtFileURL(`C:\path\repo?ref=a\b`); out != `file://C:/path/repo?ref=a\b` {
+		t.Fatalf("bad output: %q", out)
+	}
+}
@@ -3,12 +3,25 @@ package getter
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 )
 
+// gcsBucketPattern matches the characters allowed in a GCS bucket name.
+var gcsBucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*[a-z0-9]$`)
+
 // GCSDetector implements Detector to detect GCS URLs and turn
 // them into URLs that the GCSGetter can understand.
-type GCSDetector struct{}
+type GCSDetector struct {
+	// Anonymous, if true, adds the "anon=true" parameter to detected URLs
+	// so that the GCS getter fetches them without credentials, as for
+	// public buckets.
+	Anonymous bool
+}
+
+func (d *GCSDetector) Name() string {
+	return "gcs"
+}
 
 func (d *GCSDetector) Detect(src, _ string) (string, bool, error) {
 	if len(src) == 0 {
@@ -32,12 +45,62 @@ func (d *GCSDetector) detectHTTP(src string) (string, bool, error) {
 	version := parts[2]
 	bucket := parts[3]
 	object := strings.Join(parts[4:], "/")
-
 	url, err := url.Parse(fmt.Sprintf("https://www.googleapis.com/storage/%s/%s/%s",
 		version, bucket, object))
 	if err != nil {
 		return "", false, fmt.Errorf("error parsing GCS URL: %s", err)
 	}
 
+	if d.Anonymous {
+		addAnonymousParam(url)
+	}
+
 	return "gcs::" + url.String(), true, nil
 }
+
+// validateGCSBucket checks the bucket of GCS sources on the JSON API host,
+// as in "https://www.googleapis.com/storage/v1/bucket/object", against the
+// GCS bucket naming rules so that typos are caught early. Sources with
+// another layout, such as virtual-hosted ones, are left alone.
+func validateGCSBucket(r *DetectResult) error {
+	if r.Force != "gcs" {
+		return nil
+	}
+
+	u, err := url.Parse(r.Source)
+	if err != nil || !strings.EqualFold(u.Host, "www.googleapis.com") {
+		return nil
+	}
+	parts := strings.SplitN(u.Path, "/", 5)
+	if len(parts) < 4 || parts[1] != "storage" {
+		return nil
+	}
+	bucket := parts[3]
+
+	maxLen := 63
+	if strings.Contains(bucket, ".") {
+		maxLen = 222
+	}
+
+	switch {
+	case len(bucket) < 3 || len(bucket) > maxLen:
+		return fmt.Errorf("invalid GCS bucket name %q: must be 3-63 "+
+			"characters long, or up to 222 if it contains dots", bucket)
+	case !gcsBucketPattern.MatchString(bucket):
+		return fmt.Errorf("invalid GCS bucket name %q: must be lowercase "+
+			"letters, digits, dots, hyphens or underscores and start and "+
+			"end with a letter or digit", bucket)
+	case strings.HasPrefix(bucket, "goog") || strings.Contains(bucket, "google"):
+		return fmt.Errorf("invalid GCS bucket name %q: must not start with "+
+			"\"goog\" or contain \"google\"", bucket)
+	}
+
+	for _, component := range strings.Split(bucket, ".") {
+		if len(component) > 63 {
+			return fmt.Errorf("invalid GCS bucket name %q: dot-separated "+
+				"components must be at most 63 characters", bucket)
+		}
+	}
+
+	return nil
+}
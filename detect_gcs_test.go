@@ -39,3 +39,71 @@ func TestGCSDetector(t *testing.T) {
 		}
 	}
 }
+
+func TestGCSDetector_strict(t *testing.T) {
+	cases := []struct {
+		Input string
+		Err   bool
+	}{
+		{"www.googleapis.com/storage/v1/my_bucket-1/foo", false},
+		{"www.googleapis.com/storage/v1/example.com/foo", false},
+		{"www.googleapis.com/storage/v1/MyBucket/foo", true},
+		{"www.googleapis.com/storage/v1/goog-bucket/foo", true},
+		{"www.googleapis.com/storage/v1/my-google-bucket/foo", true},
+		{"www.googleapis.com/storage/v1/-bucket/foo", true},
+	}
+
+	pwd := "/pwd"
+	ds := []Detector{new(GCSDetector)}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			_, err := Detect(tc.Input, pwd, ds, WithStrictDetect())
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %s", err)
+			}
+		})
+	}
+
+	// Sources with another layout don't have the bucket in the same place.
+	for _, src := range []string{
+		"gcs::https://my-bucket.storage.googleapis.com/MyKey",
+		"gcs::https://storage.googleapis.com/my-bucket/MyKey",
+	} {
+		if _, err := Detect(src, pwd, ds, WithStrictDetect()); err != nil {
+			t.Fatalf("%s: err: %s", src, err)
+		}
+	}
+}
+
+func TestGCSDetector_anonymous(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"www.googleapis.com/storage/v1/bucket/foo",
+			"gcs::https://www.googleapis.com/storage/v1/bucket/foo?anon=true",
+		},
+		{
+			"www.googleapis.com/storage/v1/bucket/foo?generation=1",
+			"gcs::https://www.googleapis.com/storage/v1/bucket/foo?generation=1&anon=true",
+		},
+	}
+
+	pwd := "/pwd"
+	f := &GCSDetector{Anonymous: true}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, ok, err := f.Detect(tc.Input, pwd)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v", output)
+			}
+		})
+	}
+}
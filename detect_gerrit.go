@@ -0,0 +1,52 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// GerritDetector implements Detector to detect shorthand URLs for
+// repositories on a Gerrit server, such as "review.example.com/project"
+// or "review.example.com/a/project", and turn them into URLs that the
+// Git getter can understand.
+//
+// Gerrit serves authenticated clones under an "/a/" path prefix. That
+// prefix is preserved as-is so that the clone uses the same endpoint.
+type GerritDetector struct {
+	// Host is the Gerrit server to detect, such as "review.example.com".
+	// If this is empty, nothing is detected.
+	Host string
+}
+
+func (d *GerritDetector) Name() string {
+	return "gerrit"
+}
+
+func (d *GerritDetector) Detect(src, _ string) (string, bool, error) {
+	if len(src) == 0 || d.Host == "" {
+		return "", false, nil
+	}
+
+	if strings.HasPrefix(src, d.Host+"/") {
+		return d.detectHTTP(src)
+	}
+
+	return "", false, nil
+}
+
+func (d *GerritDetector) detectHTTP(src string) (string, bool, error) {
+	u, err := url.Parse("https://" + src)
+	if err != nil {
+		return "", true, fmt.Errorf("error parsing Gerrit URL: %s", err)
+	}
+
+	project := strings.TrimPrefix(u.Path, "/")
+	project = strings.TrimPrefix(project, "a/")
+	if strings.Trim(project, "/") == "" {
+		return "", true, fmt.Errorf(
+			"Gerrit URLs should be %s/project or %s/a/project", d.Host, d.Host)
+	}
+
+	return "git::" + u.String(), true, nil
+}
@@ -0,0 +1,67 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestGerritDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"review.example.com/project",
+			"git::https://review.example.com/project",
+		},
+		{
+			"review.example.com/a/project",
+			"git::https://review.example.com/a/project",
+		},
+		{
+			"review.example.com/a/group/project?ref=main",
+			"git::https://review.example.com/a/group/project?ref=main",
+		},
+		{
+			"review.example.com/group/project//modules/a",
+			"git::https://review.example.com/group/project//modules/a",
+		},
+	}
+
+	pwd := "/pwd"
+	f := &GerritDetector{Host: "review.example.com"}
+	ds := []Detector{f}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, pwd, ds)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if output != tc.Output {
+				t.Errorf("wrong result\ninput: %s\ngot:   %s\nwant:  %s", tc.Input, output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestGerritDetector_noProject(t *testing.T) {
+	f := &GerritDetector{Host: "review.example.com"}
+	_, ok, err := f.Detect("review.example.com/a/", "")
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if !ok {
+		t.Fatal("should be ok")
+	}
+}
+
+func TestGerritDetector_otherHost(t *testing.T) {
+	f := &GerritDetector{Host: "review.example.com"}
+	_, ok, err := f.Detect("other.example.com/a/project", "")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ok {
+		t.Fatal("should not detect other hosts")
+	}
+}
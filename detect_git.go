@@ -1,26 +1,526 @@
 package getter
 
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// gitPlusSchemes are the inner schemes recognized in composite
+// "git+<scheme>" URLs such as git+https://host/repo.git.
+var gitPlusSchemes = map[string]bool{
+	"file":  true,
+	"git":   true,
+	"http":  true,
+	"https": true,
+	"ssh":   true,
+}
+
+// isGitPlusScheme reports whether scheme is a "git+<scheme>" composite
+// with a recognized inner scheme.
+func isGitPlusScheme(scheme string) bool {
+	return strings.HasPrefix(scheme, "git+") &&
+		gitPlusSchemes[strings.TrimPrefix(scheme, "git+")]
+}
+
+// gitPinnedRefPattern matches a commit or ref pinned with a trailing
+// "@<ref>" on the repository path, as in "org/repo.git@abcd1234".
+var gitPinnedRefPattern = regexp.MustCompile(`^(.+)@([0-9A-Za-z._-]+)$`)
+
+// gitHostPortPattern matches a host, numeric port and path without a user
+// or scheme, as in "git.example.com:2222/org/repo".
+var gitHostPortPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9.-]*):([0-9]+)/(.+)$`)
+
+// gitHostOnlyPattern matches an SCP-like address with an empty path, such
+// as "git@host.com:" or "git@host.com:/". A single letter before the colon
+// is a Windows drive such as "C:/" rather than a host.
+var gitHostOnlyPattern = regexp.MustCompile(`^(?:[^@/]+@)?[^:/@]{2,}:/*(?:\?.*)?$`)
+
 // GitDetector implements Detector to detect Git SSH URLs such as
 // git@host.com:dir1/dir2 and converts them to proper URLs.
-type GitDetector struct{}
+//
+// A password in the user info, as in git:token@host.com:dir1/dir2, is
+// kept in the detected URL.
+//
+// A commit or ref pinned with a trailing "@<ref>" on the path, such as
+// git@host.com:org/repo.git@abcd1234, is turned into the "ref" parameter.
+//
+// Sources forced to Git that mix an ssh:// URL with the SCP-like path
+// separator, such as git::ssh://git@host.com:dir1/dir2, are read as
+// SCP-like.
+type GitDetector struct {
+	// NormalizeIDN, if true, converts internationalized host names to
+	// their ASCII (punycode) form. By default the host is left as unicode.
+	NormalizeIDN bool
 
-func (d *GitDetector) Detect(src, _ string) (string, bool, error) {
-	if len(src) == 0 {
+	// EmitSCPForm, if true, emits the compact SCP-like form such as
+	// "git::git@host.com:dir1/dir2" instead of an ssh:// URL. Since that
+	// form can't carry a port or password, addresses with either still
+	// use ssh://.
+	EmitSCPForm bool
+
+	// PreserveTrailingSlash, if true, keeps a single trailing slash on the
+	// repository path, which some getters take to mean the contents of the
+	// directory rather than the directory itself. By default the path is
+	// left as it is.
+	PreserveTrailingSlash bool
+
+	// PreferHTTPS, if true, emits an https:// URL such as
+	// "git::https://host.com/dir1/dir2" instead of an ssh:// URL, for
+	// environments that authenticate to Git over HTTPS. The "git" user and
+	// any SSH port are dropped, unless the user has a password, in which
+	// case both are kept as the HTTPS credentials. This takes precedence
+	// over EmitSCPForm.
+	PreferHTTPS bool
+
+	// StripDefaultPort, if true, removes the default SSH port 22 from
+	// detected addresses, such as "git@[2001:db8::1]:22/org/repo.git", so
+	// that they match the same address without a port. Sources that are
+	// already ssh:// URLs are left as they are.
+	StripDefaultPort bool
+
+	// BaseURL, if set, is the URL of a Git server, such as
+	// "https://git.example.com", that bare repository names forced to Git
+	// are taken to be on. For example, "git::modules/networking" becomes
+	// "git::https://git.example.com/modules/networking.git". File paths
+	// such as "git::./foo" are not affected.
+	BaseURL string
+
+	// GitUserHosts are hosts, such as "github.com", that require the "git"
+	// user over SSH. An ssh:// URL for one of them without a user, such as
+	// "ssh://github.com/org/repo.git", gets the "git" user added and is
+	// forced to Git. Other hosts are left alone.
+	GitUserHosts []string
+
+	// DetectGitSuffix, if true, forces http:// and https:// URLs whose
+	// path ends in ".git", such as "https://git.example.com/team/repo.git",
+	// to Git, and detects shorthand such as "git.example.com/team/repo.git"
+	// as such a URL over HTTPS. It is off by default since other sources
+	// may also end in ".git".
+	DetectGitSuffix bool
+
+	// DefaultRef, if set, is the ref, such as "main", added as the "ref"
+	// parameter to detected sources that don't give one, so that they
+	// always name a ref.
+	DefaultRef string
+
+	// DetectBundles, if true, forces Git bundle files, whose path ends in
+	// ".bundle", to Git: file paths such as "./backup.bundle" become
+	// "git::file://" URLs, and http://, https:// and file:// URLs are
+	// forced as they are. Bundle file paths forced with "git::" are
+	// detected this way regardless.
+	DetectBundles bool
+
+	// RewriteGitProtocol, if set to "ssh" or "https", rewrites URLs using
+	// the unencrypted Git protocol, such as "git://host.com/org/repo.git",
+	// to "git::ssh://git@host.com/org/repo.git" or
+	// "git::https://host.com/org/repo.git". By default they are passed
+	// through as they are.
+	RewriteGitProtocol string
+
+	// RejectInsecureGit, if true, makes detection fail for URLs using the
+	// unencrypted Git protocol. This takes precedence over
+	// RewriteGitProtocol.
+	RejectInsecureGit bool
+
+	// HomeRelativePaths, if true, writes SCP-like paths relative to the
+	// home directory, as in "git@host.com:dir1/dir2", as
+	// "ssh://git@host.com/~/dir1/dir2", the way Git does, so that they
+	// differ from absolute paths such as "git@host.com:/dir1/dir2". By
+	// default both become "ssh://git@host.com/dir1/dir2".
+	HomeRelativePaths bool
+}
+
+func (d *GitDetector) Name() string {
+	return "git"
+}
+
+// ForceTokens implements ForceTokenDetector so that sources forced to Git
+// can be told apart, such as bare repository names when BaseURL is set.
+func (d *GitDetector) ForceTokens() []string {
+	return []string{"git"}
+}
+
+// DetectURL implements URLDetector to add the "git" user to ssh:// URLs
+// for the hosts in GitUserHosts, to force URLs ending in ".git" or
+// ".bundle" to Git if DetectGitSuffix or DetectBundles is set, and to
+// rewrite or reject git:// URLs.
+func (d *GitDetector) DetectURL(src string) (string, bool, error) {
+	result, ok, err := d.detectURL(src)
+	if err == nil {
+		err = checkGitArchive(src, ok)
+	}
+	if ok && err == nil {
+		result = d.addDefaultRef(result)
+	}
+	return result, ok || err != nil, err
+}
+
+func (d *GitDetector) detectURL(src string) (string, bool, error) {
+	force, rest := getForcedGetter(src)
+	if force != "" && force != "git" {
 		return "", false, nil
 	}
 
+	// A source such as "git.example.com:2222/org/repo" parses as a URL
+	// with the host as its scheme. Forced to Git, the number after the
+	// host can only be an SSH port, since an SCP-like path would need a
+	// user.
+	if force == "git" {
+		if result, ok := detectGitHostPort(rest); ok {
+			return result, true, nil
+		}
+	}
+
+	u, err := url.Parse(rest)
+	if err != nil {
+		return "", false, nil
+	}
+
+	if d.DetectGitSuffix && force == "" &&
+		(u.Scheme == "http" || u.Scheme == "https") &&
+		strings.HasSuffix(u.Path, ".git") {
+		return "git::" + rest, true, nil
+	}
+
+	if d.DetectBundles && force == "" &&
+		(u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "file") &&
+		strings.HasSuffix(u.Path, ".bundle") {
+		return "git::" + rest, true, nil
+	}
+
+	if u.Scheme == "git" {
+		return d.detectGitProtocol(u)
+	}
+
+	if u.Scheme != "ssh" || u.User != nil {
+		return "", false, nil
+	}
+
+	for _, host := range d.GitUserHosts {
+		if strings.EqualFold(u.Hostname(), host) {
+			u.User = url.User("git")
+			return "git::" + u.String(), true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+func (d *GitDetector) Detect(src, pwd string) (string, bool, error) {
+	result, ok, _, err := d.ExplainDetect(src, pwd)
+	return result, ok, err
+}
+
+// ExplainDetect implements ExplainDetector to tell why a source isn't a Git
+// SSH address.
+func (d *GitDetector) ExplainDetect(src, pwd string) (string, bool, string, error) {
+	result, ok, reason, err := d.explainDetect(src, pwd)
+	if err == nil {
+		err = checkGitArchive(src, ok)
+	}
+	if ok && err == nil {
+		result = d.addDefaultRef(result)
+	}
+	return result, ok || err != nil, reason, err
+}
+
+func (d *GitDetector) explainDetect(src, pwd string) (string, bool, string, error) {
+	if len(src) == 0 {
+		return "", false, "source is empty", nil
+	}
+
+	force, rest := getForcedGetter(src)
+	forced := force == "git"
+	if forced {
+		src = rest
+		if d.BaseURL != "" && isBareRepoName(src) {
+			result, ok, err := d.detectBaseURL(src)
+			return result, ok, "", err
+		}
+
+		// A URL such as "ssh://git@host.com:dir1/dir2" mixes in the
+		// SCP-like separator, which would make "dir1" the port. Since that
+		// port isn't numeric, this can only be meant as an SCP-like path,
+		// which is absolute as in any ssh:// URL. Real ports, as in
+		// "ssh://git@host.com:22/dir1/dir2", are valid URLs and never get
+		// here.
+		if strings.HasPrefix(src, "ssh://") {
+			if _, err := url.Parse(src); err != nil {
+				scp := absoluteSCPPath(strings.TrimPrefix(src, "ssh://"))
+				getLogger().Warnf(
+					"%q is ambiguous: reading it as the SCP-like address %q", src, scp)
+				src = scp
+			}
+		}
+	}
+
+	// A bundle is cloned from its file, so it gets a file URL.
+	if (forced || d.DetectBundles) && isBundlePath(src) {
+		result, ok, err := new(FileDetector).Detect(src, pwd)
+		if !ok || err != nil {
+			return "", ok, "", err
+		}
+		return "git::" + result, true, "", nil
+	}
+
+	if d.DetectGitSuffix && isGitSuffixShorthand(src) {
+		return "git::https://" + src, true, "", nil
+	}
+
+	// An address with only a host, such as "git@host.com:", can't name a
+	// repository.
+	if gitHostOnlyPattern.MatchString(src) {
+		if forced {
+			return "", true, "", fmt.Errorf("missing repository path: %s", src)
+		}
+		return "", false, "missing repository path", nil
+	}
+
 	u, err := detectSSH(src)
 	if err != nil {
-		return "", true, err
+		return "", true, "", err
 	}
 	if u == nil {
-		return "", false, nil
+		return "", false, "ssh detection yielded nil URL", nil
 	}
 
 	// We require the username to be "git" to assume that this is a Git URL
-	if u.User.Username() != "git" {
+	if user := u.User.Username(); user != "git" {
+		if user == "" {
+			return "", false, "ssh address has no git user", nil
+		}
+		return "", false, fmt.Sprintf("user %q is not git", user), nil
+	}
+
+	// The path follows the "git@" user, so any "@" in it pins a ref.
+	if matched := gitPinnedRefPattern.FindStringSubmatch(u.Path); matched != nil {
+		q := u.Query()
+		if q.Get("ref") != "" {
+			return "", true, "", fmt.Errorf(
+				"ref is set both with @ and the ref parameter: %s", src)
+		}
+		u.Path = matched[1]
+		q.Set("ref", matched[2])
+		u.RawQuery = q.Encode()
+	}
+
+	if d.PreserveTrailingSlash && strings.HasSuffix(u.Path, "/") {
+		u.Path = strings.TrimRight(u.Path, "/") + "/"
+	}
+
+	if d.StripDefaultPort && u.Port() == "22" {
+		u.Host = strings.TrimSuffix(u.Host, ":22")
+	}
+
+	if d.NormalizeIDN {
+		if err := normalizeIDNHost(u); err != nil {
+			return "", true, "", err
+		}
+	}
+
+	if d.PreferHTTPS {
+		u.Scheme = "https"
+		if _, ok := u.User.Password(); !ok {
+			u.User = nil
+		}
+		u.Host = u.Hostname()
+		if strings.Contains(u.Host, ":") {
+			// Restore the brackets of an IPv6 literal.
+			u.Host = "[" + u.Host + "]"
+		}
+		if !strings.HasPrefix(u.Path, "/") {
+			u.Path = "/" + u.Path
+		}
+
+		return "git::" + u.String(), true, "", nil
+	}
+
+	_, hasPassword := u.User.Password()
+	if d.EmitSCPForm && u.Port() == "" && !hasPassword {
+		return "git::" + fmtSCP(u), true, "", nil
+	}
+
+	if d.HomeRelativePaths && !strings.HasPrefix(u.Path, "/") && !strings.HasPrefix(u.Path, "~") {
+		u.Path = "~/" + u.Path
+	}
+
+	return "git::" + u.String(), true, "", nil
+}
+
+// absoluteSCPPath adds a leading slash to the path of the SCP-like address
+// scp if it doesn't have one, as in "git@host.com:/dir1/dir2".
+func absoluteSCPPath(scp string) string {
+	m := sshPattern.FindStringSubmatchIndex(scp)
+	if m == nil || strings.HasPrefix(scp[m[6]:], "/") {
+		return scp
+	}
+	return scp[:m[6]] + "/" + scp[m[6]:]
+}
+
+// detectGitProtocol rejects or rewrites the git:// URL u according to
+// RejectInsecureGit and RewriteGitProtocol. The Git protocol port is
+// dropped, since it means nothing over SSH or HTTPS.
+func (d *GitDetector) detectGitProtocol(u *url.URL) (string, bool, error) {
+	if d.RejectInsecureGit {
+		return "", true, fmt.Errorf(
+			"the unencrypted git:// protocol is not allowed: %s", u)
+	}
+
+	switch d.RewriteGitProtocol {
+	case "":
 		return "", false, nil
+	case "ssh":
+		u.User = url.User("git")
+	case "https":
+		u.User = nil
+	default:
+		return "", true, fmt.Errorf(
+			"unknown protocol %q to rewrite git:// URLs to", d.RewriteGitProtocol)
 	}
 
+	u.Scheme = d.RewriteGitProtocol
+	u.Host = u.Hostname()
+	if strings.Contains(u.Host, ":") {
+		// Restore the brackets of an IPv6 literal.
+		u.Host = "[" + u.Host + "]"
+	}
 	return "git::" + u.String(), true, nil
 }
+
+// checkGitArchive returns an error if src, a source that is forced to Git
+// or was detected as Git if detected is set, has an "archive" parameter
+// that isn't a known archive format or false. Other sources are not
+// checked.
+func checkGitArchive(src string, detected bool) error {
+	force, rest := getForcedGetter(src)
+	if force != "git" && !detected {
+		return nil
+	}
+
+	_, query := splitQuery(rest)
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return nil
+	}
+
+	for _, v := range q["archive"] {
+		if _, ok := Decompressors[v]; ok {
+			continue
+		}
+		if b, err := strconv.ParseBool(v); err == nil && !b {
+			continue
+		}
+		return fmt.Errorf("unsupported archive format %q in Git source: %s", v, src)
+	}
+	return nil
+}
+
+// addDefaultRef adds DefaultRef as the "ref" parameter of the detected
+// source src if it doesn't have one. The rest of the query is left as-is.
+func (d *GitDetector) addDefaultRef(src string) string {
+	if d.DefaultRef == "" {
+		return src
+	}
+
+	_, query := splitQuery(src)
+	if q, err := url.ParseQuery(query); err == nil && q.Get("ref") != "" {
+		return src
+	}
+
+	if query == "" {
+		src = strings.TrimSuffix(src, "?") + "?"
+	} else {
+		src += "&"
+	}
+	return src + "ref=" + url.QueryEscape(d.DefaultRef)
+}
+
+func (d *GitDetector) detectBaseURL(src string) (string, bool, error) {
+	u, err := url.Parse(d.BaseURL)
+	if err != nil {
+		return "", true, fmt.Errorf("error parsing Git base URL: %s", err)
+	}
+
+	name, query := splitQuery(src)
+	u.Path = path.Join("/", u.Path, name)
+	if !strings.HasSuffix(u.Path, ".git") {
+		u.Path += ".git"
+	}
+	u.RawQuery = query
+
+	return "git::" + u.String(), true, nil
+}
+
+// detectGitHostPort turns a host, port and path such as
+// "git.example.com:2222/org/repo" into an ssh:// URL with the "git" user.
+func detectGitHostPort(src string) (string, bool) {
+	src, query := splitQuery(src)
+	matched := gitHostPortPattern.FindStringSubmatch(src)
+	if matched == nil {
+		return "", false
+	}
+
+	repo := matched[3]
+	if !strings.HasSuffix(repo, ".git") {
+		repo += ".git"
+	}
+
+	u := &url.URL{
+		Scheme:   "ssh",
+		User:     url.User("git"),
+		Host:     matched[1] + ":" + matched[2],
+		Path:     "/" + repo,
+		RawQuery: query,
+	}
+	return "git::" + u.String(), true
+}
+
+// isGitSuffixShorthand reports whether src is shorthand for a repository
+// over HTTPS, such as "git.example.com/team/repo.git", whose first path
+// segment is a host name and whose path ends in ".git".
+func isGitSuffixShorthand(src string) bool {
+	p, _ := splitQuery(src)
+	if !strings.HasSuffix(p, ".git") {
+		return false
+	}
+
+	idx := strings.Index(p, "/")
+	if idx == -1 {
+		return false
+	}
+	host := p[:idx]
+	return strings.Contains(host, ".") && !strings.HasPrefix(host, ".") &&
+		!strings.ContainsAny(host, `:@\`)
+}
+
+// isBundlePath reports whether src is a file path to a Git bundle, such as
+// "./backup.bundle", rather than a URL or SCP-like address.
+func isBundlePath(src string) bool {
+	p, _ := splitQuery(src)
+	if !strings.HasSuffix(p, ".bundle") {
+		return false
+	}
+	return strings.HasPrefix(p, ".") || strings.HasPrefix(p, "~") ||
+		strings.HasPrefix(p, "/") || filepath.IsAbs(p)
+}
+
+// isBareRepoName reports whether src is a repository name such as
+// "modules/networking" rather than a file path, URL or SCP-like address.
+func isBareRepoName(src string) bool {
+	switch {
+	case src == "":
+		return false
+	case strings.HasPrefix(src, ".") || strings.HasPrefix(src, "~"):
+		return false
+	case filepath.IsAbs(src) || strings.HasPrefix(src, "/"):
+		return false
+	case strings.ContainsAny(src, `:\`):
+		return false
+	}
+	return true
+}
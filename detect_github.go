@@ -3,26 +3,79 @@ package getter
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 )
 
+// gistIDPattern matches the ID of a GitHub Gist.
+var gistIDPattern = regexp.MustCompile(`^[0-9a-f]+$`)
+
 // GitHubDetector implements Detector to detect GitHub URLs and turn
 // them into URLs that the Git Getter can understand.
-type GitHubDetector struct{}
+//
+// Gists, such as "gist.github.com/user/id" or "gist.github.com/id", are
+// detected too, since they are Git repositories as well.
+//
+// A source forced with "github::", such as "github::git.example.com/org/repo",
+// is detected the same way for any host.
+type GitHubDetector struct {
+	// EnterpriseHosts are the hosts of GitHub Enterprise servers, such as
+	// "github.example.com", that are detected the same way as github.com.
+	EnterpriseHosts []string
+}
+
+func (d *GitHubDetector) Name() string {
+	return "github"
+}
+
+func (d *GitHubDetector) ForceTokens() []string {
+	return []string{"github"}
+}
 
 func (d *GitHubDetector) Detect(src, _ string) (string, bool, error) {
 	if len(src) == 0 {
 		return "", false, nil
 	}
 
+	if force, rest := getForcedGetter(src); force == "github" {
+		return d.detectHTTP(rest)
+	}
+
 	if strings.HasPrefix(src, "github.com/") {
 		return d.detectHTTP(src)
 	}
+	if strings.HasPrefix(src, "gist.github.com/") {
+		return d.detectGist(src)
+	}
+
+	for _, host := range d.EnterpriseHosts {
+		if len(src) > len(host) && strings.EqualFold(src[:len(host)], host) &&
+			src[len(host)] == '/' {
+			return d.detectHTTP(src)
+		}
+	}
 
 	return "", false, nil
 }
 
+// DetectURL implements URLDetector so that a URL forced with "github::",
+// such as "github::https://git.example.com/org/repo", is detected as well.
+func (d *GitHubDetector) DetectURL(src string) (string, bool, error) {
+	force, rest := getForcedGetter(src)
+	if force != "github" {
+		return "", false, nil
+	}
+
+	u, err := url.Parse(rest)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false, nil
+	}
+
+	return d.detectHTTP(strings.TrimPrefix(rest, u.Scheme+"://"))
+}
+
 func (d *GitHubDetector) detectHTTP(src string) (string, bool, error) {
+	src, rawQuery := splitQuery(src)
 	parts := strings.Split(src, "/")
 	if len(parts) < 3 {
 		return "", false, fmt.Errorf(
@@ -42,6 +95,33 @@ func (d *GitHubDetector) detectHTTP(src string) (string, bool, error) {
 	if len(parts) > 3 {
 		url.Path += "//" + strings.Join(parts[3:], "/")
 	}
+	url.RawQuery = rawQuery
 
 	return "git::" + url.String(), true, nil
 }
+
+func (d *GitHubDetector) detectGist(src string) (string, bool, error) {
+	src, rawQuery := splitQuery(src)
+	parts := strings.Split(strings.TrimSuffix(src, "/"), "/")
+
+	// The user is optional and not part of the repository URL.
+	var id string
+	switch len(parts) {
+	case 2:
+		id = parts[1]
+	case 3:
+		id = parts[2]
+	}
+	id = strings.TrimSuffix(id, ".git")
+	if !gistIDPattern.MatchString(id) {
+		return "", true, fmt.Errorf(
+			"GitHub Gist URLs should be gist.github.com/[username/]id")
+	}
+
+	result := fmt.Sprintf("git::https://gist.github.com/%s.git", id)
+	if rawQuery != "" {
+		result += "?" + rawQuery
+	}
+
+	return result, true, nil
+}
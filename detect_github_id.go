@@ -0,0 +1,115 @@
+package getter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GitHubRepoIDResolver looks up GitHub repositories by their numeric ID.
+type GitHubRepoIDResolver interface {
+	// ResolveRepoID returns the full name, such as "hashicorp/go-getter",
+	// of the repository with the given ID.
+	ResolveRepoID(ctx context.Context, id int64) (string, error)
+}
+
+// GitHubIDDetector implements Detector to detect GitHub repositories
+// referenced by numeric ID under the "ghid" forced getter, such as
+// "ghid::1234567", and turn them into URLs that the Git Getter can
+// understand.
+//
+// This detector isn't in the default Detectors since it needs to make an
+// API call for every source.
+type GitHubIDDetector struct {
+	// Resolver looks up repository IDs. If this is nil, the public GitHub
+	// API is used.
+	Resolver GitHubRepoIDResolver
+}
+
+func (d *GitHubIDDetector) Name() string {
+	return "ghid"
+}
+
+func (d *GitHubIDDetector) ForceTokens() []string {
+	return []string{"ghid"}
+}
+
+// NeedsNetwork implements NetworkDetector since the detector looks up
+// repositories with an API.
+func (d *GitHubIDDetector) NeedsNetwork() bool {
+	return true
+}
+
+func (d *GitHubIDDetector) Detect(src, pwd string) (string, bool, error) {
+	return d.DetectContext(context.Background(), src, pwd)
+}
+
+// DetectContext implements ContextDetector so that the repository lookup
+// can be canceled.
+func (d *GitHubIDDetector) DetectContext(ctx context.Context, src, _ string) (string, bool, error) {
+	force, src := getForcedGetter(src)
+	if force != "ghid" {
+		return "", false, nil
+	}
+
+	idStr, query := splitQuery(src)
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		return "", true, fmt.Errorf(
+			"GitHub repository IDs should be positive numbers: %s", idStr)
+	}
+
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = new(gitHubAPIResolver)
+	}
+
+	name, err := resolver.ResolveRepoID(ctx, id)
+	if err != nil {
+		return "", true, fmt.Errorf("error looking up GitHub repository %d: %s", id, err)
+	}
+	if strings.Count(name, "/") != 1 {
+		return "", true, fmt.Errorf(
+			"unexpected name for GitHub repository %d: %s", id, name)
+	}
+
+	result := fmt.Sprintf("git::https://github.com/%s.git", name)
+	if query != "" {
+		result += "?" + query
+	}
+
+	return result, true, nil
+}
+
+// gitHubAPIResolver resolves repository IDs with the public GitHub API.
+type gitHubAPIResolver struct{}
+
+func (r *gitHubAPIResolver) ResolveRepoID(ctx context.Context, id int64) (string, error) {
+	var info struct {
+		FullName string `json:"full_name"`
+	}
+
+	infoUrl := fmt.Sprintf("https://api.github.com/repositories/%d", id)
+	req, err := http.NewRequestWithContext(ctx, "GET", infoUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	if err := dec.Decode(&info); err != nil {
+		return "", err
+	}
+
+	return info.FullName, nil
+}
@@ -0,0 +1,60 @@
+package getter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type testGitHubRepoIDResolver map[int64]string
+
+func (r testGitHubRepoIDResolver) ResolveRepoID(_ context.Context, id int64) (string, error) {
+	name, ok := r[id]
+	if !ok {
+		return "", fmt.Errorf("not found")
+	}
+	return name, nil
+}
+
+func TestGitHubIDDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+		Err    bool
+	}{
+		{"ghid::1234", "git::https://github.com/hashicorp/go-getter.git", false},
+		{"ghid::1234?ref=v1.0.0", "git::https://github.com/hashicorp/go-getter.git?ref=v1.0.0", false},
+		{"ghid::1234//helper/url", "git::https://github.com/hashicorp/go-getter.git//helper/url", false},
+		{"ghid::5678", "", true},
+		{"ghid::abc", "", true},
+	}
+
+	f := &GitHubIDDetector{
+		Resolver: testGitHubRepoIDResolver{1234: "hashicorp/go-getter"},
+	}
+	ds := []Detector{f}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", ds)
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestGitHubIDDetector_notForced(t *testing.T) {
+	f := &GitHubIDDetector{
+		Resolver: testGitHubRepoIDResolver{1234: "hashicorp/go-getter"},
+	}
+	_, ok, err := f.Detect("1234", "/pwd")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ok {
+		t.Fatal("should not detect without the ghid forced getter")
+	}
+}
@@ -24,6 +24,10 @@ func TestGitHubDetector(t *testing.T) {
 			"github.com/hashicorp/foo.git?foo=bar",
 			"git::https://github.com/hashicorp/foo.git?foo=bar",
 		},
+		{
+			"github.com/hashicorp/foo/bar/baz.yaml?ref=v1",
+			"git::https://github.com/hashicorp/foo.git//bar/baz.yaml?ref=v1",
+		},
 	}
 
 	pwd := "/pwd"
@@ -42,3 +46,129 @@ func TestGitHubDetector(t *testing.T) {
 		}
 	}
 }
+
+func TestGitHubDetector_badEscape(t *testing.T) {
+	f := new(GitHubDetector)
+	_, ok, err := f.Detect("github.com/hashicorp/100%foo", "/pwd")
+	if err == nil {
+		t.Fatal("should error")
+	}
+	if !ok {
+		t.Fatal("should be ok")
+	}
+}
+
+func TestGitHubDetector_gist(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"gist.github.com/0123456789abcdef0123",
+			"git::https://gist.github.com/0123456789abcdef0123.git",
+		},
+		{
+			"gist.github.com/mitchellh/0123456789abcdef0123",
+			"git::https://gist.github.com/0123456789abcdef0123.git",
+		},
+		{
+			"gist.github.com/mitchellh/0123456789abcdef0123.git?ref=fedcba98",
+			"git::https://gist.github.com/0123456789abcdef0123.git?ref=fedcba98",
+		},
+		{
+			"gist.github.com/0123456789abcdef0123?ref=fedcba98",
+			"git::https://gist.github.com/0123456789abcdef0123.git?ref=fedcba98",
+		},
+	}
+
+	pwd := "/pwd"
+	f := new(GitHubDetector)
+	for i, tc := range cases {
+		output, ok, err := f.Detect(tc.Input, pwd)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !ok {
+			t.Fatal("not ok")
+		}
+
+		if output != tc.Output {
+			t.Fatalf("%d: bad: %#v", i, output)
+		}
+	}
+}
+
+func TestGitHubDetector_badGist(t *testing.T) {
+	cases := []string{
+		"gist.github.com/",
+		"gist.github.com/mitchellh/not-an-id",
+		"gist.github.com/mitchellh/0123abcd/raw",
+	}
+
+	f := new(GitHubDetector)
+	for _, tc := range cases {
+		t.Run(tc, func(t *testing.T) {
+			_, ok, err := f.Detect(tc, "/pwd")
+			if err == nil {
+				t.Fatal("should error")
+			}
+			if !ok {
+				t.Fatal("should be ok")
+			}
+		})
+	}
+}
+
+func TestGitHubDetector_enterprise(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"github.com/hashicorp/foo",
+			"git::https://github.com/hashicorp/foo.git",
+		},
+		{
+			"github.mycorp.com/team/repo//modules/vpc?ref=v1",
+			"git::https://github.mycorp.com/team/repo.git//modules/vpc?ref=v1",
+		},
+		{
+			"GitHub.MyCorp.com/team/repo",
+			"git::https://GitHub.MyCorp.com/team/repo.git",
+		},
+		{
+			"github::git.example.com/team/repo?ref=v1",
+			"git::https://git.example.com/team/repo.git?ref=v1",
+		},
+		{
+			"github::https://git.example.com/team/repo",
+			"git::https://git.example.com/team/repo.git",
+		},
+
+		// Hosts that aren't configured aren't GitHub.
+		{
+			"github.other.com/team/repo",
+			"file:///pwd/github.other.com/team/repo",
+		},
+		{
+			"github.mycorp.community/team/repo",
+			"file:///pwd/github.mycorp.community/team/repo",
+		},
+	}
+
+	ds := []Detector{
+		&GitHubDetector{EnterpriseHosts: []string{"github.mycorp.com"}},
+		new(FileDetector),
+	}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", ds)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
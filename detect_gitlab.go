@@ -0,0 +1,104 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GitLabDetector implements Detector to detect GitLab URLs and turn
+// them into URLs that the Git Getter can understand.
+//
+// Since GitLab allows nested groups, the whole path is taken as the
+// project path. Web UI URLs such as "gitlab.com/group/project/-/tree/ref/path"
+// (or "/-/blob/") are turned into the project with the "ref" parameter and
+// subdir set. The ref is always the first segment after "tree" or "blob".
+//
+// Wiki repositories, such as "gitlab.com/group/project.wiki" or
+// "gitlab.com/group/project/-/wikis", and snippets, such as
+// "gitlab.com/-/snippets/123" or "gitlab.com/group/project/-/snippets/456",
+// are turned into their clonable repositories as well.
+type GitLabDetector struct{}
+
+func (d *GitLabDetector) Name() string {
+	return "gitlab"
+}
+
+func (d *GitLabDetector) Detect(src, _ string) (string, bool, error) {
+	if len(src) == 0 {
+		return "", false, nil
+	}
+
+	if strings.HasPrefix(src, "gitlab.com/") {
+		return d.detectHTTP(src)
+	}
+
+	return "", false, nil
+}
+
+func (d *GitLabDetector) detectHTTP(src string) (string, bool, error) {
+	u, err := url.Parse("https://" + src)
+	if err != nil {
+		return "", true, fmt.Errorf("error parsing GitLab URL: %s", err)
+	}
+
+	path := strings.Trim(u.Path, "/")
+
+	// Personal snippets aren't in a project.
+	if p := strings.TrimPrefix(path, "-/"); p == "snippets" || strings.HasPrefix(p, "snippets/") {
+		return d.snippet(u, "snippets", strings.TrimPrefix(p, "snippets/"))
+	}
+
+	var ref, subdir string
+	if idx := strings.Index(path, "/-/"); idx > -1 {
+		parts := strings.Split(path[idx+3:], "/")
+		switch {
+		case len(parts) == 1 && parts[0] == "wikis":
+			path = path[:idx] + ".wiki"
+		case len(parts) == 2 && parts[0] == "snippets":
+			return d.snippet(u, path[:idx]+"/snippets", parts[1])
+		case len(parts) < 2 || (parts[0] != "tree" && parts[0] != "blob") || parts[1] == "":
+			return "", true, fmt.Errorf(
+				"GitLab web URLs should be gitlab.com/group/project/-/tree/ref/path")
+		default:
+			path = path[:idx]
+			ref = parts[1]
+			subdir = strings.Join(parts[2:], "/")
+		}
+	}
+
+	if !strings.Contains(path, "/") {
+		return "", true, fmt.Errorf(
+			"GitLab URLs should be gitlab.com/group/project")
+	}
+
+	u.Path = "/" + path
+	if !strings.HasSuffix(u.Path, ".git") {
+		u.Path += ".git"
+	}
+
+	if subdir != "" {
+		u.Path += "//" + subdir
+	}
+
+	if ref != "" {
+		q := u.Query()
+		q.Set("ref", ref)
+		u.RawQuery = q.Encode()
+	}
+
+	return "git::" + u.String(), true, nil
+}
+
+// snippet returns the repository of the snippet with the given ID, where
+// prefix is the path of the snippets it belongs to.
+func (d *GitLabDetector) snippet(u *url.URL, prefix, id string) (string, bool, error) {
+	id = strings.TrimSuffix(id, ".git")
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return "", true, fmt.Errorf("GitLab snippet IDs should be numbers: %s", id)
+	}
+
+	u.Path = "/" + prefix + "/" + id + ".git"
+	return "git::" + u.String(), true, nil
+}
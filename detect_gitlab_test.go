@@ -0,0 +1,121 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestGitLabDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"gitlab.com/hashicorp/foo", "git::https://gitlab.com/hashicorp/foo.git"},
+		{"gitlab.com/hashicorp/foo.git", "git::https://gitlab.com/hashicorp/foo.git"},
+		{
+			"gitlab.com/hashicorp/group/foo",
+			"git::https://gitlab.com/hashicorp/group/foo.git",
+		},
+		{
+			"gitlab.com/hashicorp/foo?ref=v1.0.0",
+			"git::https://gitlab.com/hashicorp/foo.git?ref=v1.0.0",
+		},
+		{
+			"gitlab.com/hashicorp/foo/-/tree/main",
+			"git::https://gitlab.com/hashicorp/foo.git?ref=main",
+		},
+		{
+			"gitlab.com/hashicorp/group/foo/-/tree/v1.0.0/modules/vpc",
+			"git::https://gitlab.com/hashicorp/group/foo.git//modules/vpc?ref=v1.0.0",
+		},
+		{
+			"gitlab.com/hashicorp/foo/-/blob/main/modules/vpc/main.tf",
+			"git::https://gitlab.com/hashicorp/foo.git//modules/vpc/main.tf?ref=main",
+		},
+	}
+
+	pwd := "/pwd"
+	f := new(GitLabDetector)
+	for i, tc := range cases {
+		output, ok, err := f.Detect(tc.Input, pwd)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !ok {
+			t.Fatal("not ok")
+		}
+
+		if output != tc.Output {
+			t.Fatalf("%d: bad: %#v", i, output)
+		}
+	}
+}
+
+func TestGitLabDetector_bad(t *testing.T) {
+	cases := []string{
+		"gitlab.com/hashicorp",
+		"gitlab.com/hashicorp/foo/-/tree",
+		"gitlab.com/hashicorp/foo/-/issues/1",
+		"gitlab.com/hashicorp/foo/-/snippets/abc",
+		"gitlab.com/-/snippets/",
+	}
+
+	f := new(GitLabDetector)
+	for _, input := range cases {
+		_, ok, err := f.Detect(input, "/pwd")
+		if err == nil {
+			t.Fatalf("%s: should error", input)
+		}
+		if !ok {
+			t.Fatalf("%s: should be ok", input)
+		}
+	}
+}
+
+func TestGitLabDetector_wikiAndSnippets(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"gitlab.com/hashicorp/foo.wiki",
+			"git::https://gitlab.com/hashicorp/foo.wiki.git",
+		},
+		{
+			"gitlab.com/hashicorp/group/foo.wiki.git",
+			"git::https://gitlab.com/hashicorp/group/foo.wiki.git",
+		},
+		{
+			"gitlab.com/hashicorp/foo/-/wikis",
+			"git::https://gitlab.com/hashicorp/foo.wiki.git",
+		},
+		{
+			"gitlab.com/-/snippets/123",
+			"git::https://gitlab.com/snippets/123.git",
+		},
+		{
+			"gitlab.com/snippets/123.git?ref=main",
+			"git::https://gitlab.com/snippets/123.git?ref=main",
+		},
+		{
+			"gitlab.com/hashicorp/group/foo/-/snippets/456",
+			"git::https://gitlab.com/hashicorp/group/foo/snippets/456.git",
+		},
+	}
+
+	pwd := "/pwd"
+	f := new(GitLabDetector)
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, ok, err := f.Detect(tc.Input, pwd)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v", output)
+			}
+		})
+	}
+}
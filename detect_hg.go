@@ -0,0 +1,41 @@
+package getter
+
+// HgDetector implements Detector to detect Mercurial SSH URLs such as
+// hg@host.com:dir1/dir2 and converts them to proper URLs.
+type HgDetector struct {
+	// NormalizeIDN, if true, converts internationalized host names to
+	// their ASCII (punycode) form. By default the host is left as unicode.
+	NormalizeIDN bool
+}
+
+func (d *HgDetector) Name() string {
+	return "hg"
+}
+
+func (d *HgDetector) Detect(src, _ string) (string, bool, error) {
+	if len(src) == 0 {
+		return "", false, nil
+	}
+
+	u, err := detectSSH(src)
+	if err != nil {
+		return "", true, err
+	}
+	if u == nil {
+		return "", false, nil
+	}
+
+	// We require the username to be "hg" to assume that this is a Mercurial
+	// URL
+	if u.User.Username() != "hg" {
+		return "", false, nil
+	}
+
+	if d.NormalizeIDN {
+		if err := normalizeIDNHost(u); err != nil {
+			return "", true, err
+		}
+	}
+
+	return "hg::" + u.String(), true, nil
+}
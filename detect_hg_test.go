@@ -0,0 +1,56 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestHgDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"hg@bitbucket.org:hashicorp/foo",
+			"hg::ssh://hg@bitbucket.org/hashicorp/foo",
+		},
+		{
+			"hg@bitbucket.org:hashicorp/foo?rev=default",
+			"hg::ssh://hg@bitbucket.org/hashicorp/foo?rev=default",
+		},
+		{
+			"hg@bitbucket.org:hashicorp/foo//bar",
+			"hg::ssh://hg@bitbucket.org/hashicorp/foo//bar",
+		},
+		{
+			"hg::ssh://hg@hg.example.com:2222/hashicorp/foo",
+			"hg::ssh://hg@hg.example.com:2222/hashicorp/foo",
+		},
+	}
+
+	pwd := "/pwd"
+	f := new(HgDetector)
+	ds := []Detector{f}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, pwd, ds)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if output != tc.Output {
+				t.Errorf("wrong result\ninput: %s\ngot:   %s\nwant:  %s", tc.Input, output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestHgDetector_notHgUser(t *testing.T) {
+	f := new(HgDetector)
+	_, ok, err := f.Detect("git@github.com:hashicorp/foo.git", "")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ok {
+		t.Fatal("should not detect non-hg user")
+	}
+}
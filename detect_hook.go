@@ -0,0 +1,38 @@
+package getter
+
+import (
+	"sync"
+	"time"
+)
+
+// DetectHook is called after each detector runs during detection, such as
+// to record metrics. It is given the name of the detector, or its type if
+// it isn't a NamedDetector, whether it matched the source, how long it
+// took and the error it returned, if any.
+type DetectHook func(detector string, matched bool, dur time.Duration, err error)
+
+var (
+	detectHookLock sync.RWMutex
+	detectHook     DetectHook
+)
+
+// SetDetectHook sets the DetectHook that is called after each detector
+// runs. A nil DetectHook, the default, turns it off. The hook doesn't
+// change the result of detection.
+func SetDetectHook(h DetectHook) {
+	detectHookLock.Lock()
+	defer detectHookLock.Unlock()
+	detectHook = h
+}
+
+// runDetectHook calls the DetectHook set with SetDetectHook, if any, for
+// detector d that was started at start.
+func runDetectHook(d Detector, start time.Time, matched bool, err error) {
+	detectHookLock.RLock()
+	h := detectHook
+	detectHookLock.RUnlock()
+
+	if h != nil {
+		h(detectorLabel(d), matched, time.Since(start), err)
+	}
+}
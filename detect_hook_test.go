@@ -0,0 +1,51 @@
+package getter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSetDetectHook(t *testing.T) {
+	type call struct {
+		Detector string
+		Matched  bool
+		Err      bool
+	}
+
+	var calls []call
+	SetDetectHook(func(detector string, matched bool, dur time.Duration, err error) {
+		if dur < 0 {
+			t.Errorf("negative duration for %s: %s", detector, dur)
+		}
+		calls = append(calls, call{detector, matched, err != nil})
+	})
+	defer SetDetectHook(nil)
+
+	ds := []Detector{new(GitHubDetector), new(GitDetector), new(FileDetector), new(S3Detector)}
+	output, err := Detect("./foo", "/pwd", ds)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if output != "file:///pwd/foo" {
+		t.Fatalf("bad: %s", output)
+	}
+
+	expected := []call{
+		{"github", false, false},
+		{"git", false, false},
+		{"file", true, false},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("bad calls: %#v\nexpected: %#v", calls, expected)
+	}
+
+	calls = nil
+	if _, err := Detect("foo", "/pwd", []Detector{errorTestDetector{}}); err == nil {
+		t.Fatal("expected error")
+	}
+	expected = []call{{"getter.errorTestDetector", true, true}}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("bad calls: %#v\nexpected: %#v", calls, expected)
+	}
+}
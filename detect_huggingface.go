@@ -0,0 +1,86 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// HuggingFaceDetector implements Detector to detect Hugging Face Hub
+// repositories, such as "huggingface.co/org/model" or "hf.co/org/model",
+// and turn them into URLs that the Git Getter can understand.
+//
+// Repositories without an organization, such as "huggingface.co/gpt2",
+// are detected too. Datasets and spaces, such as
+// "huggingface.co/datasets/org/name", keep their "datasets/" or "spaces/"
+// prefix as part of the repository path.
+// Web URLs that name a revision and path with "/tree/" or "/blob/" are
+// turned into the repository with the "ref" parameter and subdir set.
+type HuggingFaceDetector struct{}
+
+func (d *HuggingFaceDetector) Name() string {
+	return "huggingface"
+}
+
+func (d *HuggingFaceDetector) Detect(src, _ string) (string, bool, error) {
+	if len(src) == 0 {
+		return "", false, nil
+	}
+
+	if strings.HasPrefix(src, "huggingface.co/") {
+		return d.detectHTTP(strings.TrimPrefix(src, "huggingface.co/"))
+	}
+	if strings.HasPrefix(src, "hf.co/") {
+		return d.detectHTTP(strings.TrimPrefix(src, "hf.co/"))
+	}
+
+	return "", false, nil
+}
+
+func (d *HuggingFaceDetector) detectHTTP(src string) (string, bool, error) {
+	u, err := url.Parse("https://huggingface.co/" + src)
+	if err != nil {
+		return "", true, fmt.Errorf("error parsing Hugging Face URL: %s", err)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	// The kind of repository is part of its path.
+	var repo []string
+	if parts[0] == "datasets" || parts[0] == "spaces" {
+		repo, parts = parts[:1], parts[1:]
+	}
+	// Repositories such as "gpt2" have no organization.
+	n := 2
+	if len(parts) == 1 || (len(parts) > 1 && (parts[1] == "tree" || parts[1] == "blob")) {
+		n = 1
+	}
+	if len(parts) < n || parts[0] == "" || parts[n-1] == "" {
+		return "", true, fmt.Errorf(
+			"Hugging Face URLs should be huggingface.co/[datasets/|spaces/][org/]name")
+	}
+	repo, parts = append(repo, parts[:n]...), parts[n:]
+
+	var ref, subdir string
+	if len(parts) > 0 {
+		if len(parts) < 2 || (parts[0] != "tree" && parts[0] != "blob") || parts[1] == "" {
+			return "", true, fmt.Errorf(
+				"Hugging Face web URLs should be huggingface.co/org/name/tree/ref/path")
+		}
+		ref = parts[1]
+		subdir = strings.Join(parts[2:], "/")
+	}
+
+	u.Path = "/" + strings.Join(repo, "/")
+	if subdir != "" {
+		u.Path += "//" + subdir
+	}
+
+	if ref != "" {
+		q := u.Query()
+		q.Set("ref", ref)
+		u.RawQuery = q.Encode()
+	}
+
+	return "git::" + u.String(), true, nil
+}
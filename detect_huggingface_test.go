@@ -0,0 +1,92 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestHuggingFaceDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		// Models
+		{"huggingface.co/org/model", "git::https://huggingface.co/org/model"},
+		{"hf.co/org/model", "git::https://huggingface.co/org/model"},
+		{"huggingface.co/gpt2", "git::https://huggingface.co/gpt2"},
+		{"huggingface.co/gpt2/tree/main/onnx", "git::https://huggingface.co/gpt2//onnx?ref=main"},
+		{"huggingface.co/org/model?ref=v1.0", "git::https://huggingface.co/org/model?ref=v1.0"},
+		{
+			"huggingface.co/org/model/tree/main/onnx",
+			"git::https://huggingface.co/org/model//onnx?ref=main",
+		},
+
+		// Datasets
+		{"huggingface.co/datasets/org/data", "git::https://huggingface.co/datasets/org/data"},
+		{"hf.co/datasets/org/data?ref=abc123", "git::https://huggingface.co/datasets/org/data?ref=abc123"},
+		{"huggingface.co/datasets/squad", "git::https://huggingface.co/datasets/squad"},
+
+		// Spaces
+		{"huggingface.co/spaces/org/app", "git::https://huggingface.co/spaces/org/app"},
+		{
+			"huggingface.co/spaces/org/app/blob/v2/app.py",
+			"git::https://huggingface.co/spaces/org/app//app.py?ref=v2",
+		},
+	}
+
+	pwd := "/pwd"
+	f := new(HuggingFaceDetector)
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, ok, err := f.Detect(tc.Input, pwd)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v", output)
+			}
+		})
+	}
+}
+
+func TestHuggingFaceDetector_bad(t *testing.T) {
+	cases := []string{
+		"huggingface.co/",
+		"huggingface.co/datasets",
+		"hf.co/spaces/",
+		"huggingface.co/org/model/commits/main",
+		"huggingface.co/org/model/tree",
+	}
+
+	f := new(HuggingFaceDetector)
+	for _, input := range cases {
+		_, ok, err := f.Detect(input, "/pwd")
+		if err == nil {
+			t.Fatalf("%s: should error", input)
+		}
+		if !ok {
+			t.Fatalf("%s: should be ok", input)
+		}
+	}
+}
+
+func TestDetect_huggingFace(t *testing.T) {
+	output, err := Detect("huggingface.co/datasets/org/data//train?ref=v1", "/pwd", Detectors)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if expected := "git::https://huggingface.co/datasets/org/data//train?ref=v1"; output != expected {
+		t.Fatalf("bad: %#v", output)
+	}
+
+	// The default detectors accept models without an organization.
+	output, err = Detect("huggingface.co/gpt2", "/pwd", Detectors)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if expected := "git::https://huggingface.co/gpt2"; output != expected {
+		t.Fatalf("bad: %#v", output)
+	}
+}
@@ -0,0 +1,69 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// LaunchpadDetector implements Detector to detect Launchpad Git
+// repositories, such as "git.launchpad.net/project" or
+// "launchpad.net/+git/project", and turn them into URLs that the Git
+// Getter can understand.
+//
+// Repositories owned by a person or team, as in "launchpad.net/~user/project"
+// or "git.launchpad.net/~user/project/+git/repo", keep the "~user" segment
+// as-is. Launchpad's Bazaar branches aren't detected since there is no
+// Bazaar getter.
+type LaunchpadDetector struct{}
+
+func (d *LaunchpadDetector) Name() string {
+	return "launchpad"
+}
+
+func (d *LaunchpadDetector) Detect(src, _ string) (string, bool, error) {
+	if len(src) == 0 {
+		return "", false, nil
+	}
+
+	if strings.HasPrefix(src, "git.launchpad.net/") {
+		return d.detectHTTP(strings.TrimPrefix(src, "git.launchpad.net/"))
+	}
+	if strings.HasPrefix(src, "launchpad.net/") {
+		src = strings.TrimPrefix(src, "launchpad.net/")
+		return d.detectHTTP(strings.TrimPrefix(src, "+git/"))
+	}
+
+	return "", false, nil
+}
+
+func (d *LaunchpadDetector) detectHTTP(src string) (string, bool, error) {
+	u, err := url.Parse("https://git.launchpad.net/" + src)
+	if err != nil {
+		return "", true, fmt.Errorf("error parsing Launchpad URL: %s", err)
+	}
+
+	if err := validateLaunchpadPath(u.Path); err != nil {
+		return "", true, err
+	}
+
+	return "git::" + u.String(), true, nil
+}
+
+// validateLaunchpadPath checks that path is of the form "project",
+// "~user/project" or either of those followed by "+git/repo".
+func validateLaunchpadPath(path string) error {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		if part == "" || (i > 0 && strings.HasPrefix(part, "~")) {
+			return fmt.Errorf(
+				"Launchpad URLs should be git.launchpad.net/[~user/]project")
+		}
+	}
+
+	if parts[0] == "~" || (strings.HasPrefix(parts[0], "~") && len(parts) < 2) {
+		return fmt.Errorf(
+			"Launchpad URLs should be git.launchpad.net/[~user/]project")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestLaunchpadDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		// Project repositories
+		{"git.launchpad.net/project", "git::https://git.launchpad.net/project"},
+		{"launchpad.net/project", "git::https://git.launchpad.net/project"},
+		{"launchpad.net/+git/project", "git::https://git.launchpad.net/project"},
+		{"git.launchpad.net/project?ref=v1", "git::https://git.launchpad.net/project?ref=v1"},
+		{
+			"launchpad.net/project/+git/extra",
+			"git::https://git.launchpad.net/project/+git/extra",
+		},
+
+		// Owner-scoped repositories
+		{"git.launchpad.net/~user/project", "git::https://git.launchpad.net/~user/project"},
+		{"launchpad.net/~user/project", "git::https://git.launchpad.net/~user/project"},
+		{
+			"launchpad.net/~user/project/+git/repo",
+			"git::https://git.launchpad.net/~user/project/+git/repo",
+		},
+		{
+			"git.launchpad.net/~team/+git/repo?ref=main",
+			"git::https://git.launchpad.net/~team/+git/repo?ref=main",
+		},
+	}
+
+	pwd := "/pwd"
+	f := new(LaunchpadDetector)
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, ok, err := f.Detect(tc.Input, pwd)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v", output)
+			}
+		})
+	}
+}
+
+func TestLaunchpadDetector_bad(t *testing.T) {
+	cases := []string{
+		"git.launchpad.net/",
+		"git.launchpad.net/~user",
+		"git.launchpad.net/~/project",
+		"launchpad.net/project//extra",
+		"launchpad.net/project/~user",
+	}
+
+	f := new(LaunchpadDetector)
+	for _, input := range cases {
+		_, ok, err := f.Detect(input, "/pwd")
+		if err == nil {
+			t.Fatalf("%s: should error", input)
+		}
+		if !ok {
+			t.Fatalf("%s: should be ok", input)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package getter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ociTagPattern matches a valid OCI image tag.
+var ociTagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]{0,127}$`)
+
+// ociDigestPattern matches a valid OCI content digest such as
+// "sha256:<hex>".
+var ociDigestPattern = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[A-Za-z0-9=_-]+$`)
+
+// DefaultOCIRegistries are the registry hosts detected by OCIDetector when
+// its Registries field is empty.
+var DefaultOCIRegistries = []string{
+	"docker.io",
+	"gcr.io",
+	"ghcr.io",
+	"public.ecr.aws",
+	"quay.io",
+}
+
+// OCIDetector implements Detector to detect references to artifacts in an
+// OCI registry, such as "ghcr.io/org/module:1.2.3", and turn them into
+// URLs of the form "oci::https://ghcr.io/org/module?ref=1.2.3". A digest,
+// as in "ghcr.io/org/module@sha256:...", is kept in the "digest" parameter.
+//
+// Bare references are only detected for known registry hosts. A source
+// forced with "oci::", or using the "oci://" scheme, is detected for any
+// host.
+//
+// This detector isn't in the default Detectors since there is no getter
+// for OCI artifacts in this package.
+type OCIDetector struct {
+	// Registries are the registry hosts, such as "ghcr.io", that bare
+	// references are detected for. If this is empty, DefaultOCIRegistries
+	// is used.
+	Registries []string
+}
+
+func (d *OCIDetector) Name() string {
+	return "oci"
+}
+
+func (d *OCIDetector) ForceTokens() []string {
+	return []string{"oci"}
+}
+
+func (d *OCIDetector) Detect(src, _ string) (string, bool, error) {
+	if len(src) == 0 {
+		return "", false, nil
+	}
+
+	force, src := getForcedGetter(src)
+	if force == "oci" {
+		return d.detectReference(src)
+	}
+	if force != "" {
+		return "", false, nil
+	}
+
+	host := src
+	if idx := strings.Index(host, "/"); idx > -1 {
+		host = host[:idx]
+	}
+	for _, registry := range d.registries() {
+		if host == registry {
+			return d.detectReference(src)
+		}
+	}
+
+	return "", false, nil
+}
+
+func (d *OCIDetector) registries() []string {
+	if len(d.Registries) > 0 {
+		return d.Registries
+	}
+	return DefaultOCIRegistries
+}
+
+func (d *OCIDetector) detectReference(src string) (string, bool, error) {
+	ref, query := splitQuery(src)
+
+	parts := strings.Split(ref, "/")
+	if len(parts) < 2 || parts[0] == "" {
+		return "", true, fmt.Errorf(
+			"OCI references should be registry/repository[:tag][@digest]")
+	}
+
+	for _, part := range parts[1 : len(parts)-1] {
+		if part == "" || strings.ContainsAny(part, ":@") {
+			return "", true, fmt.Errorf("invalid OCI repository %q", ref)
+		}
+	}
+
+	name := parts[len(parts)-1]
+	var tag, digest string
+	if idx := strings.Index(name, "@"); idx > -1 {
+		name, digest = name[:idx], name[idx+1:]
+		if !ociDigestPattern.MatchString(digest) {
+			return "", true, fmt.Errorf("invalid OCI digest %q", digest)
+		}
+	}
+	if idx := strings.Index(name, ":"); idx > -1 {
+		name, tag = name[:idx], name[idx+1:]
+		if !ociTagPattern.MatchString(tag) {
+			return "", true, fmt.Errorf("invalid OCI tag %q", tag)
+		}
+	}
+	if name == "" {
+		return "", true, fmt.Errorf(
+			"OCI references should be registry/repository[:tag][@digest]")
+	}
+	parts[len(parts)-1] = name
+
+	// The tag and digest are validated above, so they can be used in the
+	// query as-is. This keeps the digest readable as "sha256:...".
+	var params []string
+	if tag != "" {
+		params = append(params, "ref="+tag)
+	}
+	if digest != "" {
+		params = append(params, "digest="+digest)
+	}
+	if query != "" {
+		params = append(params, query)
+	}
+
+	result := "oci::https://" + strings.Join(parts, "/")
+	if len(params) > 0 {
+		result += "?" + strings.Join(params, "&")
+	}
+
+	return result, true, nil
+}
@@ -0,0 +1,139 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestOCIDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"ghcr.io/org/module", "oci::https://ghcr.io/org/module"},
+		{"ghcr.io/org/module:1.2.3", "oci::https://ghcr.io/org/module?ref=1.2.3"},
+		{
+			"ghcr.io/org/group/module:1.2.3",
+			"oci::https://ghcr.io/org/group/module?ref=1.2.3",
+		},
+		{
+			"ghcr.io/org/module@sha256:0123abcd",
+			"oci::https://ghcr.io/org/module?digest=sha256:0123abcd",
+		},
+		{
+			"ghcr.io/org/module:1.2.3@sha256:0123abcd",
+			"oci::https://ghcr.io/org/module?ref=1.2.3&digest=sha256:0123abcd",
+		},
+		{
+			"ghcr.io/org/module:1.2.3?archive=tar",
+			"oci::https://ghcr.io/org/module?ref=1.2.3&archive=tar",
+		},
+		{
+			"oci::registry.example.com:5000/org/module:1.2.3",
+			"oci::https://registry.example.com:5000/org/module?ref=1.2.3",
+		},
+	}
+
+	pwd := "/pwd"
+	f := new(OCIDetector)
+	for i, tc := range cases {
+		output, ok, err := f.Detect(tc.Input, pwd)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !ok {
+			t.Fatalf("%d: not ok", i)
+		}
+
+		if output != tc.Output {
+			t.Fatalf("%d: bad: %#v", i, output)
+		}
+	}
+}
+
+func TestOCIDetector_registries(t *testing.T) {
+	f := &OCIDetector{Registries: []string{"registry.example.com"}}
+
+	output, ok, err := f.Detect("registry.example.com/org/module:1.0", "")
+	if err != nil || !ok {
+		t.Fatalf("expected detection, got ok=%v err=%v", ok, err)
+	}
+	if expected := "oci::https://registry.example.com/org/module?ref=1.0"; output != expected {
+		t.Fatalf("bad: %#v", output)
+	}
+
+	// Only the configured registries are detected.
+	if _, ok, _ := f.Detect("ghcr.io/org/module:1.0", ""); ok {
+		t.Fatal("expected ghcr.io not to be detected")
+	}
+}
+
+func TestOCIDetector_bad(t *testing.T) {
+	cases := []string{
+		"ghcr.io",
+		"ghcr.io/org/:1.2.3",
+		"ghcr.io/org//module",
+		"ghcr.io/org/module:bad/tag",
+		"ghcr.io/org/module:.bad",
+		"ghcr.io/org/module@sha256",
+		"oci::/org/module",
+	}
+
+	f := new(OCIDetector)
+	for _, tc := range cases {
+		t.Run(tc, func(t *testing.T) {
+			_, ok, err := f.Detect(tc, "")
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !ok {
+				t.Fatal("should be ok")
+			}
+		})
+	}
+}
+
+func TestDetect_oci(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"ghcr.io/org/module:1.2.3//modules/vpc",
+			"oci::https://ghcr.io/org/module//modules/vpc?ref=1.2.3",
+		},
+		{
+			"oci://ghcr.io/org/module:1.2.3",
+			"oci::https://ghcr.io/org/module?ref=1.2.3",
+		},
+		{
+			"oci://registry.example.com/org/module@sha256:0123abcd",
+			"oci::https://registry.example.com/org/module?digest=sha256:0123abcd",
+		},
+		{
+			"oci::registry.example.com/org/module:1.2.3",
+			"oci::https://registry.example.com/org/module?ref=1.2.3",
+		},
+	}
+
+	ds := []Detector{new(OCIDetector), new(FileDetector)}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", ds)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v", output)
+			}
+		})
+	}
+
+	// Without a detector claiming "oci", an oci:// URL is left alone.
+	output, err := Detect("oci://ghcr.io/org/module:1.2.3", "", Detectors)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if output != "oci://ghcr.io/org/module:1.2.3" {
+		t.Fatalf("bad: %#v", output)
+	}
+}
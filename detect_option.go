@@ -0,0 +1,382 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A DetectOption allows to configure detection.
+type DetectOption func(*detectOptions) error
+
+// detectOptions holds the configuration set by DetectOptions.
+type detectOptions struct {
+	// strict enables extra validation of the detected source.
+	strict bool
+
+	// unquote strips balanced quotes around the source.
+	unquote bool
+
+	// requireHTTPS rejects sources that use plain HTTP.
+	requireHTTPS bool
+
+	// stripGitForce removes a "git" forced getter from the result.
+	stripGitForce bool
+
+	// offline skips detectors that need the network.
+	offline bool
+
+	// redetect runs detection again on detected sources until they no
+	// longer change.
+	redetect bool
+
+	// hostAllowlist, if not nil, replaces HostAllowlist.
+	hostAllowlist []string
+
+	// expandEnv expands environment variables in the source.
+	expandEnv bool
+
+	// expandEnvStrict makes undefined environment variables an error.
+	expandEnvStrict bool
+
+	// knownSchemes, if not nil, are the only forced getters and schemes
+	// that detected sources may use.
+	knownSchemes map[string]bool
+
+	// getterTokens, if not nil, replaces the keys of Getters as the forced
+	// getters that sources may use without a detector claiming them.
+	getterTokens []string
+
+	// resolveFrom, if set, is the directory that relative sources are
+	// resolved from instead of pwd.
+	resolveFrom string
+}
+
+// configure applies the given options.
+func (o *detectOptions) configure(opts ...DetectOption) error {
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filter returns the detectors of ds that may be used with the configured
+// options, along with the names of those that may not.
+func (o *detectOptions) filter(ds []Detector) ([]Detector, []string) {
+	if !o.offline {
+		return ds, nil
+	}
+
+	var kept []Detector
+	var skipped []string
+	for _, d := range ds {
+		if nd, ok := d.(NetworkDetector); ok && nd.NeedsNetwork() {
+			skipped = append(skipped, detectorLabel(d))
+			continue
+		}
+		kept = append(kept, d)
+	}
+	return kept, skipped
+}
+
+// checkForceToken returns an error if the source src is forced to a getter
+// that no detector in ds claims and that isn't a known getter, such as a
+// mistyped "gti::".
+func (o *detectOptions) checkForceToken(src string, ds []Detector) error {
+	force, _, _ := splitSource(src)
+	if force == "" || claimedByAny(ds, force) {
+		return nil
+	}
+
+	if o.getterTokens != nil {
+		for _, token := range o.getterTokens {
+			if strings.EqualFold(token, force) {
+				return nil
+			}
+		}
+	} else if _, ok := Getters[force]; ok {
+		return nil
+	}
+
+	return fmt.Errorf("no detector handles force token %q: %s", force, src)
+}
+
+// resolvePwd returns the directory that detectors should resolve relative
+// sources from, which is resolveFrom if set, resolved against pwd if it is
+// relative.
+func (o *detectOptions) resolvePwd(pwd string) (string, error) {
+	if o.resolveFrom == "" {
+		return pwd, nil
+	}
+	if isAbsPwd(o.resolveFrom) {
+		return o.resolveFrom, nil
+	}
+	if pwd == "" || !isAbsPwd(pwd) {
+		return "", fmt.Errorf(
+			"relative directory to resolve from requires an absolute pwd: %s",
+			o.resolveFrom)
+	}
+	return filepath.Join(pwd, o.resolveFrom), nil
+}
+
+// prepare applies the configured options to the source before it is
+// detected.
+func (o *detectOptions) prepare(src string) (string, error) {
+	if o.unquote {
+		src = unquoteSource(src)
+	}
+	if o.expandEnv {
+		var err error
+		src, err = expandEnv(src, o.expandEnvStrict)
+		if err != nil {
+			return "", err
+		}
+	}
+	return src, nil
+}
+
+// validate checks a detected result from the detectors ds against the
+// configured options.
+func (o *detectOptions) validate(r *DetectResult, ds []Detector) error {
+	if o.strict {
+		if err := validateCharset(r); err != nil {
+			return err
+		}
+		if err := validateS3Bucket(r, ds); err != nil {
+			return err
+		}
+		if err := validateGCSBucket(r); err != nil {
+			return err
+		}
+	}
+	allowlist := o.hostAllowlist
+	if allowlist == nil {
+		allowlist = HostAllowlist
+	}
+	if len(allowlist) > 0 {
+		if err := checkHostAllowed(r, allowlist); err != nil {
+			return err
+		}
+	}
+	if RejectEmbeddedCredentials {
+		if err := checkCredentials(r); err != nil {
+			return err
+		}
+	}
+	if o.knownSchemes != nil {
+		if err := o.checkScheme(r); err != nil {
+			return err
+		}
+	}
+	if o.requireHTTPS {
+		u, err := url.Parse(r.Source)
+		if err == nil && strings.EqualFold(u.Scheme, "http") {
+			return fmt.Errorf(
+				"source must use https rather than http: %s", r.String())
+		}
+	}
+	return nil
+}
+
+// checkScheme returns an error if the detected result r uses a forced
+// getter, or if not forced a scheme, that isn't one of knownSchemes.
+func (o *detectOptions) checkScheme(r *DetectResult) error {
+	scheme := r.Force
+	if scheme == "" {
+		u, err := url.Parse(r.Source)
+		if err != nil {
+			return nil
+		}
+		scheme = u.Scheme
+	}
+
+	if scheme != "" && !o.knownSchemes[strings.ToLower(scheme)] {
+		return fmt.Errorf("unknown scheme %q in source: %s", scheme, r.String())
+	}
+	return nil
+}
+
+// finish applies the configured options to a validated result.
+func (o *detectOptions) finish(r *DetectResult) {
+	if o.stripGitForce && r.Force == "git" {
+		r.Force = ""
+	}
+}
+
+// WithStrictDetect enables extra validation of detected sources, such as
+// rejecting an unknown "charset" query parameter or an S3 or GCS bucket
+// name that breaks the naming rules.
+func WithStrictDetect() DetectOption {
+	return func(o *detectOptions) error {
+		o.strict = true
+		return nil
+	}
+}
+
+// WithUnquoteSource strips a matching pair of single or double quotes
+// around the source before detection, as left behind by sources pasted
+// from a shell.
+func WithUnquoteSource() DetectOption {
+	return func(o *detectOptions) error {
+		o.unquote = true
+		return nil
+	}
+}
+
+// unquoteSource removes a balanced pair of outer single or double quotes
+// from src. Surrounding whitespace is ignored.
+func unquoteSource(src string) string {
+	trimmed := strings.TrimSpace(src)
+	if len(trimmed) < 2 {
+		return src
+	}
+
+	first, last := trimmed[0], trimmed[len(trimmed)-1]
+	if first != last || (first != '"' && first != '\'') {
+		return src
+	}
+
+	return trimmed[1 : len(trimmed)-1]
+}
+
+// WithRequireHTTPS rejects detected sources that use plain http://,
+// including when forced to another getter such as "git::http://". Other
+// schemes are not affected.
+func WithRequireHTTPS() DetectOption {
+	return func(o *detectOptions) error {
+		o.requireHTTPS = true
+		return nil
+	}
+}
+
+// WithStripGitForce removes the "git::" forced getter from detected
+// sources, such as turning "git::ssh://git@host/org/repo.git" into
+// "ssh://git@host/org/repo.git", for tools that take bare Git URLs. The
+// subdir and query, such as "?ref=", are kept.
+func WithStripGitForce() DetectOption {
+	return func(o *detectOptions) error {
+		o.stripGitForce = true
+		return nil
+	}
+}
+
+// WithOffline skips all detectors that need the network, as reported by
+// NetworkDetector, so that detection never makes an outbound request. If
+// no other detector matches, the NoMatchError lists the skipped ones.
+func WithOffline() DetectOption {
+	return func(o *detectOptions) error {
+		o.offline = true
+		return nil
+	}
+}
+
+// WithRedetect runs detection again on each detected source until it no
+// longer changes, so that detectors may expand a source into another
+// shorthand. To prevent detectors that keep rewriting each other's
+// results from looping forever, detection fails after maxRedetect passes.
+func WithRedetect() DetectOption {
+	return func(o *detectOptions) error {
+		o.redetect = true
+		return nil
+	}
+}
+
+// WithHostAllowlist rejects detected sources for hosts other than the
+// given ones, with the same patterns as HostAllowlist, which it replaces
+// for the call. Giving no hosts turns off the package-level HostAllowlist.
+func WithHostAllowlist(hosts ...string) DetectOption {
+	return func(o *detectOptions) error {
+		o.hostAllowlist = append([]string{}, hosts...)
+		return nil
+	}
+}
+
+// WithExpandEnv expands environment variables such as "${GIT_HOST}" in the
+// source before detection. Undefined variables expand to nothing, and "$$"
+// is a literal "$".
+func WithExpandEnv() DetectOption {
+	return func(o *detectOptions) error {
+		o.expandEnv = true
+		return nil
+	}
+}
+
+// WithExpandEnvStrict is like WithExpandEnv but fails detection if the
+// source refers to an undefined environment variable.
+func WithExpandEnvStrict() DetectOption {
+	return func(o *detectOptions) error {
+		o.expandEnv = true
+		o.expandEnvStrict = true
+		return nil
+	}
+}
+
+// expandEnv expands the environment variables in src, with "$$" left as a
+// literal "$". If strict is set, undefined variables are an error.
+func expandEnv(src string, strict bool) (string, error) {
+	var undefined []string
+	result := os.Expand(src, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			undefined = append(undefined, name)
+		}
+		return value
+	})
+
+	if strict && len(undefined) > 0 {
+		return "", fmt.Errorf(
+			"undefined environment variables in source: %s",
+			strings.Join(undefined, ", "))
+	}
+	return result, nil
+}
+
+// WithKnownSchemes rejects detected sources whose forced getter, or scheme
+// if they aren't forced, isn't one of the given schemes, such as a
+// mistyped "gti::" or "htps://". With no schemes, the keys of Getters are
+// used. By default any scheme is passed through.
+func WithKnownSchemes(schemes ...string) DetectOption {
+	return func(o *detectOptions) error {
+		known := schemes
+		if len(known) == 0 {
+			known = make([]string, 0, len(Getters))
+			for scheme := range Getters {
+				known = append(known, scheme)
+			}
+		}
+
+		o.knownSchemes = make(map[string]bool, len(known))
+		for _, scheme := range known {
+			o.knownSchemes[strings.ToLower(scheme)] = true
+		}
+		return nil
+	}
+}
+
+// WithGetterTokens sets the forced getters, such as "git" in "git::./foo",
+// that sources may use even though no detector claims them, in place of
+// the keys of Getters. Detection fails for sources forced to any other
+// getter that no detector claims.
+func WithGetterTokens(tokens ...string) DetectOption {
+	return func(o *detectOptions) error {
+		o.getterTokens = append([]string{}, tokens...)
+		return nil
+	}
+}
+
+// WithResolveFrom resolves relative sources from dir rather than from the
+// pwd. A relative dir is itself resolved against the pwd, which must then
+// be given and absolute.
+func WithResolveFrom(dir string) DetectOption {
+	return func(o *detectOptions) error {
+		o.resolveFrom = dir
+		return nil
+	}
+}
@@ -0,0 +1,427 @@
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDetect_unquote(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+		Err    bool
+	}{
+		{`"./my dir/module"`, "file:///pwd/my dir/module", false},
+		{`'./module'`, "file:///pwd/module", false},
+		{` "git::./module" `, "git::file:///pwd/module", false},
+		{`"./module'`, `file:///pwd/"./module'`, false},
+		{`./"module"`, `file:///pwd/"module"`, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", Detectors, WithUnquoteSource())
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestDetect_requireHTTPS(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+		Err    bool
+	}{
+		{"https://example.com/foo.zip", "https://example.com/foo.zip", false},
+		{"http://example.com/foo.zip", "", true},
+		{"HTTP://example.com/foo.zip", "", true},
+		{"git::http://example.com/foo.git", "", true},
+		{"git::https://example.com/foo.git", "git::https://example.com/foo.git", false},
+		{"github.com/hashicorp/foo", "git::https://github.com/hashicorp/foo.git", false},
+		{"git@github.com:hashicorp/foo.git", "git::ssh://git@github.com/hashicorp/foo.git", false},
+		{"./foo", "file:///pwd/foo", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", Detectors, WithRequireHTTPS())
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+
+	// Without the option plain HTTP is allowed.
+	if _, err := Detect("http://example.com/foo.zip", "/pwd", Detectors); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestDetect_stripGitForce(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Default  string
+		Stripped string
+	}{
+		{
+			"git@github.com:hashicorp/foo.git",
+			"git::ssh://git@github.com/hashicorp/foo.git",
+			"ssh://git@github.com/hashicorp/foo.git",
+		},
+		{
+			"git@github.com:hashicorp/foo.git//bar?ref=v1.0.0",
+			"git::ssh://git@github.com/hashicorp/foo.git//bar?ref=v1.0.0",
+			"ssh://git@github.com/hashicorp/foo.git//bar?ref=v1.0.0",
+		},
+		{
+			"github.com/hashicorp/foo?ref=main",
+			"git::https://github.com/hashicorp/foo.git?ref=main",
+			"https://github.com/hashicorp/foo.git?ref=main",
+		},
+		{
+			"git::https://example.com/foo.git",
+			"git::https://example.com/foo.git",
+			"https://example.com/foo.git",
+		},
+		{
+			"hg::https://example.com/foo",
+			"hg::https://example.com/foo",
+			"hg::https://example.com/foo",
+		},
+		{"./foo", "file:///pwd/foo", "file:///pwd/foo"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Default {
+				t.Fatalf("bad default output: %s\nexpected: %s", output, tc.Default)
+			}
+
+			output, err = Detect(tc.Input, "/pwd", Detectors, WithStripGitForce())
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Stripped {
+				t.Fatalf("bad stripped output: %s\nexpected: %s", output, tc.Stripped)
+			}
+		})
+	}
+}
+
+// networkTestDetector is a NetworkDetector that counts its calls in place
+// of making requests.
+type networkTestDetector struct {
+	calls int
+}
+
+func (d *networkTestDetector) NeedsNetwork() bool {
+	return true
+}
+
+func (d *networkTestDetector) Detect(src, _ string) (string, bool, error) {
+	d.calls++
+	return "https://example.com/" + src, true, nil
+}
+
+// countingRepoIDResolver is a GitHubRepoIDResolver that counts its calls.
+type countingRepoIDResolver struct {
+	calls int
+}
+
+func (r *countingRepoIDResolver) ResolveRepoID(_ context.Context, _ int64) (string, error) {
+	r.calls++
+	return "hashicorp/go-getter", nil
+}
+
+func TestDetect_offline(t *testing.T) {
+	network := new(networkTestDetector)
+	resolver := new(countingRepoIDResolver)
+	ds := []Detector{
+		network,
+		&GitHubIDDetector{Resolver: resolver},
+		new(BitBucketDetector),
+		new(GitHubDetector),
+	}
+
+	// Offline detectors still match.
+	output, err := Detect("github.com/hashicorp/foo", "", ds, WithOffline())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if output != "git::https://github.com/hashicorp/foo.git" {
+		t.Fatalf("bad output: %s", output)
+	}
+
+	for _, src := range []string{"ghid::1234", "bitbucket.org/hashicorp/foo"} {
+		_, err = Detect(src, "", ds, WithOffline())
+		if !errors.Is(err, ErrNoMatch) {
+			t.Fatalf("%s: expected ErrNoMatch, got: %v", src, err)
+		}
+
+		var noMatch *NoMatchError
+		if !errors.As(err, &noMatch) {
+			t.Fatalf("%s: expected NoMatchError, got: %#v", src, err)
+		}
+		expected := []string{"*getter.networkTestDetector", "ghid", "bitbucket"}
+		if fmt.Sprint(noMatch.Skipped) != fmt.Sprint(expected) {
+			t.Fatalf("%s: bad skipped: %v", src, noMatch.Skipped)
+		}
+		if !strings.Contains(err.Error(), "skipped detectors that need the network") {
+			t.Fatalf("%s: bad error: %s", src, err)
+		}
+	}
+
+	if network.calls != 0 || resolver.calls != 0 {
+		t.Fatalf("network detectors were called: %d, %d", network.calls, resolver.calls)
+	}
+
+	// Without the option they're used as usual.
+	if _, err := Detect("ghid::1234", "", ds); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if network.calls != 1 {
+		t.Fatalf("expected the network detector to be called")
+	}
+}
+
+// aliasTestDetector is a Detector that expands aliases to other sources.
+type aliasTestDetector map[string]string
+
+func (d aliasTestDetector) Detect(src, _ string) (string, bool, error) {
+	src, query := splitQuery(src)
+	result, ok := d[src]
+	if ok && query != "" {
+		result += "?" + query
+	}
+	return result, ok, nil
+}
+
+func TestDetect_redetect(t *testing.T) {
+	ds := []Detector{
+		aliasTestDetector{
+			"networking": "vpc",
+			"vpc":        "github.com/hashicorp/vpc",
+			"loop-a":     "loop-b",
+			"loop-b":     "loop-a",
+		},
+		new(GitHubDetector),
+	}
+
+	cases := []struct {
+		Input  string
+		Output string
+		Err    bool
+	}{
+		{"networking", "git::https://github.com/hashicorp/vpc.git", false},
+		{"networking//modules/a?ref=v1", "git::https://github.com/hashicorp/vpc.git//modules/a?ref=v1", false},
+		{"github.com/hashicorp/foo", "git::https://github.com/hashicorp/foo.git", false},
+		{"loop-a", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "", ds, WithRedetect())
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %s", err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "detection loop exceeded") {
+				t.Fatalf("bad err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+
+	// Without the option only one pass is made.
+	output, err := Detect("networking", "", ds)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if output != "vpc" {
+		t.Fatalf("bad output: %s", output)
+	}
+}
+
+func TestDetect_expandEnv(t *testing.T) {
+	for k, v := range map[string]string{
+		"GO_GETTER_TEST_GIT_HOST": "git.example.com",
+		"GO_GETTER_TEST_REF":      "v1.2.0",
+	} {
+		old, ok := os.LookupEnv(k)
+		os.Setenv(k, v)
+		defer func(k string) {
+			if ok {
+				os.Setenv(k, old)
+			} else {
+				os.Unsetenv(k)
+			}
+		}(k)
+	}
+	os.Unsetenv("GO_GETTER_TEST_UNDEFINED")
+
+	cases := []struct {
+		Input  string
+		Output string
+		Strict bool
+		Err    bool
+	}{
+		{
+			"git::https://${GO_GETTER_TEST_GIT_HOST}/org/repo.git?ref=$GO_GETTER_TEST_REF",
+			"git::https://git.example.com/org/repo.git?ref=v1.2.0",
+			false,
+			false,
+		},
+		{
+			"git::https://${GO_GETTER_TEST_GIT_HOST}/org/repo.git?ref=$GO_GETTER_TEST_REF",
+			"git::https://git.example.com/org/repo.git?ref=v1.2.0",
+			true,
+			false,
+		},
+		{
+			"./modules${GO_GETTER_TEST_UNDEFINED}/foo",
+			"file:///pwd/modules/foo",
+			false,
+			false,
+		},
+		{"./modules${GO_GETTER_TEST_UNDEFINED}/foo", "", true, true},
+		{"./price$$list", "file:///pwd/price$list", true, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			opt := WithExpandEnv()
+			if tc.Strict {
+				opt = WithExpandEnvStrict()
+			}
+			output, err := Detect(tc.Input, "/pwd", Detectors, opt)
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %v", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+
+	// Without the option the source is left alone.
+	output, err := Detect("./modules$GO_GETTER_TEST_REF", "/pwd", Detectors)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if expected := "file:///pwd/modules$GO_GETTER_TEST_REF"; output != expected {
+		t.Fatalf("bad output: %s\nexpected: %s", output, expected)
+	}
+}
+
+func TestDetect_knownSchemes(t *testing.T) {
+	cases := []struct {
+		Input string
+		Err   bool
+	}{
+		{"https://example.com/foo.zip", false},
+		{"HTTPS://example.com/foo.zip", false},
+		{"git::https://example.com/foo.git", false},
+		{"git::ssh://git@example.com/foo.git", false},
+		{"github.com/hashicorp/foo", false},
+		{"git@github.com:hashicorp/foo.git", false},
+		{"s3::https://s3.amazonaws.com/bucket/foo", false},
+		{"./foo", false},
+		{"htps://example.com/foo.zip", true},
+		{"gti::https://example.com/foo.git", true},
+		{"ftp://example.com/foo.zip", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			_, err := Detect(tc.Input, "/pwd", Detectors, WithKnownSchemes())
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %v", err)
+			}
+		})
+	}
+
+	// The known schemes can be given.
+	if _, err := Detect("ftp://example.com/foo.zip", "/pwd", Detectors, WithKnownSchemes("ftp")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := Detect("https://example.com/foo.zip", "/pwd", Detectors, WithKnownSchemes("ftp")); err == nil {
+		t.Fatal("expected error for a scheme that isn't known")
+	}
+
+	// Without the option unknown schemes are passed through.
+	if _, err := Detect("htps://example.com/foo.zip", "/pwd", Detectors); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestDetect_knownSchemesConcurrent(t *testing.T) {
+	opt := WithKnownSchemes()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := Detect("https://example.com/foo.zip", "/pwd", Detectors, opt); err != nil {
+					t.Errorf("err: %s", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDetect_resolveFrom(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Pwd    string
+		From   string
+		Output string
+		Err    bool
+	}{
+		{"./foo", "/pwd", "modules", "file:///pwd/modules/foo", false},
+		{"./foo", "/pwd", "../other", "file:///other/foo", false},
+		{"../foo", "/pwd", "a/b", "file:///pwd/a/foo", false},
+		{"./foo", "/pwd", "/abs", "file:///abs/foo", false},
+		{"./foo", "", "/abs", "file:///abs/foo", false},
+		{"git::./foo?ref=v1", "/pwd", "modules", "git::file:///pwd/modules/foo?ref=v1", false},
+		{"./foo", "", "modules", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input+" from "+tc.From, func(t *testing.T) {
+			output, err := Detect(tc.Input, tc.Pwd, Detectors, WithResolveFrom(tc.From))
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %v", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+
+	// A relative pwd is still an error.
+	if _, err := Detect("./foo", "pwd", Detectors, WithResolveFrom("modules")); err == nil {
+		t.Fatal("expected error for a relative pwd")
+	}
+}
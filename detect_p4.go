@@ -0,0 +1,110 @@
+package getter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// P4Detector implements Detector to detect Perforce depot paths, such as
+// "p4://perforce:1666//depot/path" or "//depot/path" on a configured
+// server, and turn them into URLs of the form
+// "p4::p4://perforce:1666/depot/path", where the URL path is the depot path
+// without its leading "//".
+//
+// Since "//" otherwise separates the subdir, this detector only applies to
+// sources forced with "p4::" or using the "p4://" scheme, so a plain
+// "//foo" in any other source is still a subdir. A subdir can be given
+// after the depot path with another "//", as in
+// "p4:://depot/path//modules/vpc".
+//
+// This detector isn't in the default Detectors since there is no getter
+// for Perforce in this package.
+type P4Detector struct {
+	// Server is the Perforce server, such as "perforce:1666", that depot
+	// paths without a server, such as "p4:://depot/path", are taken to be
+	// on.
+	Server string
+
+	// DepotRoot, if set, is the depot path, such as "//depot/modules",
+	// that relative paths such as "p4::networking" are taken to be in.
+	DepotRoot string
+}
+
+func (d *P4Detector) Name() string {
+	return "p4"
+}
+
+func (d *P4Detector) ForceTokens() []string {
+	return []string{"p4"}
+}
+
+func (d *P4Detector) Detect(src, pwd string) (string, bool, error) {
+	result, _, ok, err := d.DetectSubdir(context.Background(), src, "", pwd)
+	return result, ok, err
+}
+
+// DetectSubdir implements SubdirDetector since the depot path in a source
+// such as "p4://perforce:1666//depot/path" is split off as the subdir
+// before detection.
+func (d *P4Detector) DetectSubdir(_ context.Context, src, subDir, _ string) (string, string, bool, error) {
+	force, src := getForcedGetter(src)
+	if force != "p4" {
+		return "", subDir, false, nil
+	}
+
+	src, query := splitQuery(src)
+
+	var server, depotPath string
+	switch {
+	case strings.HasPrefix(src, "//"):
+		server, depotPath = d.Server, src
+	case isP4Server(src):
+		server = src
+		if idx := strings.Index(src, "/"); idx > -1 {
+			server, depotPath = src[:idx], "/"+src[idx:]
+		}
+		if depotPath == "" || depotPath == "//" {
+			// The depot path was split off as the subdir. Any further
+			// "//" in it separates the real subdir.
+			depotPath, subDir = SourceDirSubdir("//" + subDir)
+		}
+	default:
+		if d.DepotRoot == "" {
+			return "", subDir, true, fmt.Errorf(
+				"relative depot path %q needs a P4 depot root", src)
+		}
+		server = d.Server
+		depotPath = "//" + path.Join(strings.TrimPrefix(d.DepotRoot, "//"), src)
+	}
+
+	if server == "" {
+		return "", subDir, true, fmt.Errorf(
+			"depot path %q needs a P4 server", depotPath)
+	}
+	if depotPath == "" || depotPath == "//" {
+		return "", subDir, true, fmt.Errorf(
+			"P4 sources should be p4://server//depot/path")
+	}
+
+	u := &url.URL{
+		Scheme:   "p4",
+		Host:     server,
+		Path:     "/" + strings.TrimPrefix(depotPath, "//"),
+		RawQuery: query,
+	}
+
+	return "p4::" + u.String(), subDir, true, nil
+}
+
+// isP4Server reports whether src starts with a Perforce server address
+// such as "perforce:1666", rather than being a depot path.
+func isP4Server(src string) bool {
+	host := src
+	if idx := strings.Index(host, "/"); idx > -1 {
+		host = host[:idx]
+	}
+	return strings.Contains(host, ":")
+}
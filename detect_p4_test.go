@@ -0,0 +1,96 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestP4Detector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"p4://perforce:1666//depot/path", "p4::p4://perforce:1666/depot/path"},
+		{"p4::p4://perforce:1666//depot/path", "p4::p4://perforce:1666/depot/path"},
+		{"p4://perforce:1666/depot/path", "p4::p4://perforce:1666/depot/path"},
+		{
+			"p4://perforce:1666//depot/path//modules/vpc?rev=12",
+			"p4::p4://perforce:1666/depot/path//modules/vpc?rev=12",
+		},
+		{"p4:://depot/path", "p4::p4://p4.example.com:1666/depot/path"},
+		{
+			"p4:://depot/path//modules/vpc?rev=12",
+			"p4::p4://p4.example.com:1666/depot/path//modules/vpc?rev=12",
+		},
+		{"p4::networking", "p4::p4://p4.example.com:1666/depot/modules/networking"},
+	}
+
+	ds := []Detector{
+		&P4Detector{Server: "p4.example.com:1666", DepotRoot: "//depot/modules"},
+		new(FileDetector),
+	}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", ds)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v\nexpected: %#v", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestP4Detector_bad(t *testing.T) {
+	cases := []string{
+		"p4:://depot/path",
+		"p4::networking",
+		"p4://perforce:1666",
+	}
+
+	ds := []Detector{new(P4Detector)}
+	for _, input := range cases {
+		t.Run(input, func(t *testing.T) {
+			if _, err := Detect(input, "/pwd", ds); err == nil {
+				t.Fatal("should error")
+			}
+		})
+	}
+}
+
+// TestP4Detector_subdir checks that "//" in sources that aren't forced to
+// p4 is still a subdir.
+func TestP4Detector_subdir(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"./foo//bar", "file:///pwd/foo//bar"},
+		{"foo//depot/path", "file:///pwd/foo//depot/path"},
+		{
+			"git::https://example.com/repo.git//depot/path",
+			"git::https://example.com/repo.git//depot/path",
+		},
+	}
+
+	ds := []Detector{
+		&P4Detector{Server: "p4.example.com:1666"},
+		new(FileDetector),
+	}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", ds)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v\nexpected: %#v", output, tc.Output)
+			}
+		})
+	}
+
+	p := &P4Detector{Server: "p4.example.com:1666"}
+	if _, ok, err := p.Detect("//depot/path", "/pwd"); ok || err != nil {
+		t.Fatalf("unforced depot path should not match: %t, %v", ok, err)
+	}
+}
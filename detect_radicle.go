@@ -0,0 +1,75 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// radicleIDPattern matches a Radicle repository id, which is a base58
+// multibase string starting with "z".
+var radicleIDPattern = regexp.MustCompile(`^z[1-9A-HJ-NP-Za-km-z]+$`)
+
+// RadicleDetector implements Detector to detect Radicle repositories, such
+// as "rad://z3gqcJUoA1n9HaHKufZs5FCSGazv5" or "rad::z3gqcJUoA1n9HaHKufZs5FCSGazv5".
+//
+// A seed node can be given before the repository id, as in
+// "rad://seed.example.com/z3gqcJUoA1n9HaHKufZs5FCSGazv5". The repository is
+// then fetched from the seed over HTTPS, as in
+// "git::https://seed.example.com/z3gqcJUoA1n9HaHKufZs5FCSGazv5.git".
+// Without a seed node, detected sources are forced to "rad", as in
+// "rad::rad://z3gqcJUoA1n9HaHKufZs5FCSGazv5", for a getter that
+// fetches them over the Radicle network.
+//
+// Only sources forced with "rad::", or using the "rad://" scheme, are
+// detected. This detector isn't in the default Detectors.
+type RadicleDetector struct {
+	// Seed, if set, is the seed node, such as "seed.radicle.xyz", that
+	// repositories without one are fetched from.
+	Seed string
+}
+
+func (d *RadicleDetector) Name() string {
+	return "radicle"
+}
+
+func (d *RadicleDetector) ForceTokens() []string {
+	return []string{"rad"}
+}
+
+func (d *RadicleDetector) Detect(src, _ string) (string, bool, error) {
+	force, src := getForcedGetter(src)
+	if force != "rad" {
+		return "", false, nil
+	}
+
+	src, query := splitQuery(strings.TrimPrefix(src, "rad://"))
+
+	seed, id := d.Seed, src
+	if idx := strings.Index(src, "/"); idx > -1 {
+		seed, id = src[:idx], src[idx+1:]
+		if seed == "" {
+			return "", true, fmt.Errorf("empty seed node in Radicle source: %s", src)
+		}
+	}
+
+	// The id is opaque, so it is only checked as a whole rather than
+	// split further.
+	if !radicleIDPattern.MatchString(id) {
+		return "", true, fmt.Errorf("invalid Radicle repository id %q", id)
+	}
+
+	var result string
+	if seed != "" {
+		u := &url.URL{Scheme: "https", Host: seed, Path: "/" + id + ".git"}
+		result = "git::" + u.String()
+	} else {
+		result = "rad::rad://" + id
+	}
+	if query != "" {
+		result += "?" + query
+	}
+
+	return result, true, nil
+}
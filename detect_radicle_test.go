@@ -0,0 +1,105 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestRadicleDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		// Without a seed node
+		{
+			"rad://z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+			"rad::rad://z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+		},
+		{
+			"rad::z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+			"rad::rad://z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+		},
+		{
+			"rad::rad://z3gqcJUoA1n9HaHKufZs5FCSGazv5?ref=main",
+			"rad::rad://z3gqcJUoA1n9HaHKufZs5FCSGazv5?ref=main",
+		},
+		{
+			"rad://z3gqcJUoA1n9HaHKufZs5FCSGazv5//modules/net",
+			"rad::rad://z3gqcJUoA1n9HaHKufZs5FCSGazv5//modules/net",
+		},
+
+		// With a seed node
+		{
+			"rad://seed.example.com/z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+			"git::https://seed.example.com/z3gqcJUoA1n9HaHKufZs5FCSGazv5.git",
+		},
+		{
+			"rad://seed.example.com:8443/z3gqcJUoA1n9HaHKufZs5FCSGazv5?ref=v1",
+			"git::https://seed.example.com:8443/z3gqcJUoA1n9HaHKufZs5FCSGazv5.git?ref=v1",
+		},
+	}
+
+	ds := []Detector{new(RadicleDetector), new(FileDetector)}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", ds)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestRadicleDetector_seed(t *testing.T) {
+	d := &RadicleDetector{Seed: "seed.radicle.xyz"}
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"rad://z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+			"git::https://seed.radicle.xyz/z3gqcJUoA1n9HaHKufZs5FCSGazv5.git",
+		},
+		{
+			"rad://seed.example.com/z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+			"git::https://seed.example.com/z3gqcJUoA1n9HaHKufZs5FCSGazv5.git",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", []Detector{d})
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestRadicleDetector_bad(t *testing.T) {
+	cases := []string{
+		"rad::seed.example.com/",
+		"rad::z3gqc0OIl",
+		"rad::/z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+		"rad::seed.example.com/z3gqcJUoA1n9HaHKufZs5FCSGazv5/extra",
+	}
+
+	ds := []Detector{new(RadicleDetector)}
+	for _, input := range cases {
+		t.Run(input, func(t *testing.T) {
+			if _, err := Detect(input, "/pwd", ds); err == nil {
+				t.Fatal("should error")
+			}
+		})
+	}
+
+	// Sources that aren't forced aren't Radicle repositories.
+	if _, ok, err := new(RadicleDetector).Detect("z3gqcJUoA1n9HaHKufZs5FCSGazv5", "/pwd"); ok || err != nil {
+		t.Fatalf("should not match: %t, %v", ok, err)
+	}
+}
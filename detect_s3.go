@@ -2,26 +2,140 @@ package getter
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"regexp"
 	"strings"
 )
 
+// s3BucketPattern matches the characters allowed in an S3 bucket name.
+var s3BucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// s3PathStyleHostPattern matches the AWS hosts of path-style S3 URLs, such
+// as "s3.amazonaws.com" or "s3-eu-west-1.amazonaws.com", whose bucket is
+// the first element of the path.
+var s3PathStyleHostPattern = regexp.MustCompile(`^s3([.-][a-z0-9-]+)?\.amazonaws\.com$`)
+
 // S3Detector implements Detector to detect S3 URLs and turn
 // them into URLs that the S3 getter can understand.
-type S3Detector struct{}
+//
+// Sources on S3-compatible services such as MinIO, as in
+// "minio.internal/bucket/key", are detected for the hosts in
+// CompatibleHosts, or for any host when forced with "s3::". These services
+// are addressed path-style, so a virtual-hosted source such as
+// "bucket.minio.internal/key" is turned into one too.
+type S3Detector struct {
+	// Anonymous, if true, adds the "anon=true" parameter to detected URLs
+	// so that the S3 getter fetches them without credentials, as for
+	// public buckets.
+	Anonymous bool
+
+	// CompatibleHosts are the hosts of S3-compatible services, such as
+	// "minio.internal", whose sources are detected as S3 sources.
+	CompatibleHosts []string
+
+	// Endpoint, if set, is the base URL, such as "http://minio.internal:9000",
+	// that sources on S3-compatible services are fetched from in place of
+	// their own host. Its host is compatible as well. By default the
+	// source's host is used over HTTPS.
+	Endpoint string
+}
+
+func (d *S3Detector) Name() string {
+	return "s3"
+}
+
+func (d *S3Detector) ForceTokens() []string {
+	return []string{"s3"}
+}
 
 func (d *S3Detector) Detect(src, _ string) (string, bool, error) {
 	if len(src) == 0 {
 		return "", false, nil
 	}
 
+	force, rest := getForcedGetter(src)
+	if force == "s3" {
+		src = rest
+	}
+
 	if strings.Contains(src, ".amazonaws.com/") {
 		return d.detectHTTP(src)
 	}
 
+	if force == "s3" || d.isCompatible(src) {
+		return d.detectCompatible(src)
+	}
+
 	return "", false, nil
 }
 
+// isCompatible reports whether src is on one of the compatible hosts,
+// either path-style or virtual-hosted.
+func (d *S3Detector) isCompatible(src string) bool {
+	host := src
+	if idx := strings.IndexAny(host, "/?"); idx > -1 {
+		host = host[:idx]
+	}
+
+	for _, h := range d.compatibleHosts() {
+		if strings.EqualFold(host, h) ||
+			strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(h)) {
+			return true
+		}
+	}
+	return false
+}
+
+// compatibleHosts returns d.CompatibleHosts along with the host of
+// d.Endpoint, if any.
+func (d *S3Detector) compatibleHosts() []string {
+	hosts := d.CompatibleHosts
+	if d.Endpoint != "" {
+		if u, err := url.Parse(d.Endpoint); err == nil && u.Host != "" {
+			hosts = append(hosts[:len(hosts):len(hosts)], u.Host)
+		}
+	}
+	return hosts
+}
+
+// detectCompatible detects src on an S3-compatible service, which may be
+// virtual-hosted if its host is a bucket on one of the compatible hosts.
+func (d *S3Detector) detectCompatible(src string) (string, bool, error) {
+	src, query := splitQuery(src)
+	parts := strings.Split(src, "/")
+	host, parts := parts[0], parts[1:]
+
+	for _, h := range d.compatibleHosts() {
+		if strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(h)) {
+			parts = append([]string{host[:len(host)-len(h)-1]}, parts...)
+			host = h
+			break
+		}
+	}
+
+	if host == "" || len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", true, fmt.Errorf(
+			"URL is not a valid S3 URL: expected host/bucket/key")
+	}
+	base := "https://" + host
+	if d.Endpoint != "" {
+		base = strings.TrimSuffix(d.Endpoint, "/")
+	}
+
+	u, err := url.Parse(base + "/" + strings.Join(parts, "/"))
+	if err != nil {
+		return "", true, fmt.Errorf("error parsing S3 URL: %s", err)
+	}
+	u.RawQuery = query
+
+	if d.Anonymous {
+		addAnonymousParam(u)
+	}
+
+	return "s3::" + u.String(), true, nil
+}
+
 func (d *S3Detector) detectHTTP(src string) (string, bool, error) {
 	parts := strings.Split(src, "/")
 	if len(parts) < 2 {
@@ -47,6 +161,10 @@ func (d *S3Detector) detectPathStyle(region string, parts []string) (string, boo
 		return "", false, fmt.Errorf("error parsing S3 URL: %s", err)
 	}
 
+	if d.Anonymous {
+		addAnonymousParam(url)
+	}
+
 	return "s3::" + url.String(), true, nil
 }
 
@@ -57,5 +175,75 @@ func (d *S3Detector) detectVhostStyle(region, bucket string, parts []string) (st
 		return "", false, fmt.Errorf("error parsing S3 URL: %s", err)
 	}
 
+	if d.Anonymous {
+		addAnonymousParam(url)
+	}
+
 	return "s3::" + url.String(), true, nil
 }
+
+// addAnonymousParam adds the "anon=true" parameter to u, which the S3 and
+// GCS getters take to mean that no credentials should be used. Any other
+// parameters, and an "anon" parameter that is already set, are kept.
+func addAnonymousParam(u *url.URL) {
+	if _, ok := u.Query()["anon"]; ok {
+		return
+	}
+
+	if u.RawQuery != "" {
+		u.RawQuery += "&"
+	}
+	u.RawQuery += "anon=true"
+}
+
+// validateS3Bucket checks the bucket of path-style S3 sources against the
+// S3 bucket naming rules so that typos are caught early. Only AWS hosts
+// matching s3PathStyleHostPattern and the compatible hosts of the S3
+// detectors in ds are known to be path-style; other sources, such as
+// virtual-hosted ones, are left alone.
+func validateS3Bucket(r *DetectResult, ds []Detector) error {
+	if r.Force != "s3" {
+		return nil
+	}
+
+	u, err := url.Parse(r.Source)
+	if err != nil || !isS3PathStyleHost(u.Host, ds) {
+		return nil
+	}
+	bucket := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)[0]
+
+	switch {
+	case !s3BucketPattern.MatchString(bucket):
+		return fmt.Errorf("invalid S3 bucket name %q: must be 3-63 lowercase "+
+			"letters, digits, dots or hyphens and start and end with a "+
+			"letter or digit", bucket)
+	case strings.Contains(bucket, ".."):
+		return fmt.Errorf("invalid S3 bucket name %q: must not contain "+
+			"consecutive dots", bucket)
+	case net.ParseIP(bucket) != nil:
+		return fmt.Errorf("invalid S3 bucket name %q: must not be formatted "+
+			"as an IP address", bucket)
+	}
+
+	return nil
+}
+
+// isS3PathStyleHost reports whether host is an AWS host of path-style S3
+// URLs or one of the compatible hosts of the S3 detectors in ds.
+func isS3PathStyleHost(host string, ds []Detector) bool {
+	if s3PathStyleHostPattern.MatchString(strings.ToLower(host)) {
+		return true
+	}
+	for _, d := range ds {
+		sd, ok := d.(*S3Detector)
+		if !ok {
+			continue
+		}
+		for _, h := range sd.compatibleHosts() {
+			if strings.EqualFold(host, h) {
+				return true
+			}
+		}
+	}
+	return false
+}
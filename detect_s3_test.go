@@ -82,3 +82,185 @@ func TestS3Detector(t *testing.T) {
 		}
 	}
 }
+
+func TestS3Detector_strict(t *testing.T) {
+	cases := []struct {
+		Input string
+		Err   bool
+	}{
+		{"s3.amazonaws.com/my-bucket.logs/foo", false},
+		{"my-bucket.s3.amazonaws.com/foo", false},
+		{"s3.amazonaws.com/MyBucket/foo", true},
+		{"MyBucket.s3.amazonaws.com/foo", true},
+		{"s3.amazonaws.com/my..bucket/foo", true},
+		{"s3.amazonaws.com/ab/foo", true},
+		{"s3.amazonaws.com/192.168.1.1/foo", true},
+	}
+
+	pwd := "/pwd"
+	ds := []Detector{new(S3Detector)}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			_, err := Detect(tc.Input, pwd, ds, WithStrictDetect())
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %s", err)
+			}
+		})
+	}
+
+	// Without WithStrictDetect the bucket name isn't checked.
+	if _, err := Detect("s3.amazonaws.com/MyBucket/foo", pwd, ds); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Sources on S3-compatible services are checked too.
+	compat := []Detector{&S3Detector{CompatibleHosts: []string{"minio.internal"}}}
+	if _, err := Detect("s3::https://minio.internal/MyBucket/foo", pwd, compat, WithStrictDetect()); err == nil {
+		t.Fatal("should error")
+	}
+
+	// Virtual-hosted sources passed through don't start with the bucket.
+	for _, src := range []string{
+		"s3::https://my-bucket.s3.amazonaws.com/MyKey",
+		"s3::https://my-bucket.minio.internal/MyKey",
+	} {
+		if _, err := Detect(src, pwd, compat, WithStrictDetect()); err != nil {
+			t.Fatalf("%s: err: %s", src, err)
+		}
+	}
+}
+
+func TestS3Detector_anonymous(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"bucket.s3.amazonaws.com/foo",
+			"s3::https://s3.amazonaws.com/bucket/foo?anon=true",
+		},
+		{
+			"s3-eu-west-1.amazonaws.com/bucket/foo/bar.baz?version=1234",
+			"s3::https://s3-eu-west-1.amazonaws.com/bucket/foo/bar.baz?version=1234&anon=true",
+		},
+		{
+			"s3.amazonaws.com/bucket/foo?anon=false",
+			"s3::https://s3.amazonaws.com/bucket/foo?anon=false",
+		},
+	}
+
+	pwd := "/pwd"
+	f := &S3Detector{Anonymous: true}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, ok, err := f.Detect(tc.Input, pwd)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v", output)
+			}
+		})
+	}
+}
+
+func TestS3Detector_compatible(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Detector *S3Detector
+		Input    string
+		Output   string
+	}{
+		{
+			"path style",
+			&S3Detector{CompatibleHosts: []string{"minio.internal"}},
+			"minio.internal/bucket/foo/bar.baz",
+			"s3::https://minio.internal/bucket/foo/bar.baz",
+		},
+		{
+			"virtual hosted",
+			&S3Detector{CompatibleHosts: []string{"minio.internal"}},
+			"bucket.minio.internal/foo",
+			"s3::https://minio.internal/bucket/foo",
+		},
+		{
+			"query",
+			&S3Detector{CompatibleHosts: []string{"minio.internal"}},
+			"minio.internal/bucket/foo?region=eu-west-1&version=1",
+			"s3::https://minio.internal/bucket/foo?region=eu-west-1&version=1",
+		},
+		{
+			"endpoint",
+			&S3Detector{
+				CompatibleHosts: []string{"minio.internal"},
+				Endpoint:        "http://127.0.0.1:9000",
+			},
+			"minio.internal/bucket/foo",
+			"s3::http://127.0.0.1:9000/bucket/foo",
+		},
+		{
+			"endpoint host",
+			&S3Detector{Endpoint: "https://s3.wasabisys.com/"},
+			"bucket.s3.wasabisys.com/foo",
+			"s3::https://s3.wasabisys.com/bucket/foo",
+		},
+		{
+			"anonymous",
+			&S3Detector{CompatibleHosts: []string{"minio.internal"}, Anonymous: true},
+			"minio.internal/bucket/foo",
+			"s3::https://minio.internal/bucket/foo?anon=true",
+		},
+		{
+			"forced",
+			&S3Detector{},
+			"s3::storage.example.com/bucket/foo",
+			"s3::https://storage.example.com/bucket/foo",
+		},
+		{
+			"forced aws",
+			&S3Detector{},
+			"s3::bucket.s3.amazonaws.com/foo",
+			"s3::https://s3.amazonaws.com/bucket/foo",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			output, ok, err := tc.Detector.Detect(tc.Input, "/pwd")
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+
+	t.Run("unrecognized host", func(t *testing.T) {
+		d := &S3Detector{CompatibleHosts: []string{"minio.internal"}}
+		_, ok, err := d.Detect("storage.example.com/bucket/foo", "/pwd")
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if ok {
+			t.Fatal("should not be ok")
+		}
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		d := &S3Detector{CompatibleHosts: []string{"minio.internal"}}
+		_, ok, err := d.Detect("minio.internal/bucket", "/pwd")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !ok {
+			t.Fatal("should be ok")
+		}
+	})
+}
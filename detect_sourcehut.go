@@ -0,0 +1,70 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SourceHutDetector implements Detector to detect SourceHut URLs, such as
+// "git.sr.ht/~user/repo" or "git@git.sr.ht:~user/repo", and turn them into
+// URLs that the Git Getter can understand.
+//
+// The "~user" segment is the owner of the repository on SourceHut. It is
+// kept as-is and never expanded to a home directory.
+type SourceHutDetector struct{}
+
+func (d *SourceHutDetector) Name() string {
+	return "sourcehut"
+}
+
+func (d *SourceHutDetector) Detect(src, _ string) (string, bool, error) {
+	if len(src) == 0 {
+		return "", false, nil
+	}
+
+	if strings.HasPrefix(src, "git.sr.ht/") {
+		return d.detectHTTP(src)
+	}
+	if strings.HasPrefix(src, "git@git.sr.ht:") {
+		return d.detectSSH(src)
+	}
+
+	return "", false, nil
+}
+
+func (d *SourceHutDetector) detectHTTP(src string) (string, bool, error) {
+	u, err := url.Parse("https://" + src)
+	if err != nil {
+		return "", true, fmt.Errorf("error parsing SourceHut URL: %s", err)
+	}
+
+	if err := validateSourceHutPath(u.Path); err != nil {
+		return "", true, err
+	}
+
+	return "git::" + u.String(), true, nil
+}
+
+func (d *SourceHutDetector) detectSSH(src string) (string, bool, error) {
+	u, err := detectSSH(src)
+	if err != nil {
+		return "", true, err
+	}
+
+	if err := validateSourceHutPath(u.Path); err != nil {
+		return "", true, err
+	}
+	u.Path = "/" + strings.TrimPrefix(u.Path, "/")
+
+	return "git::" + u.String(), true, nil
+}
+
+// validateSourceHutPath checks that path is of the form "~user/repo".
+func validateSourceHutPath(path string) error {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 2 || len(parts[0]) < 2 || parts[0][0] != '~' || parts[1] == "" {
+		return fmt.Errorf("SourceHut URLs should be git.sr.ht/~user/repo")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestSourceHutDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"git.sr.ht/~user/repo", "git::https://git.sr.ht/~user/repo"},
+		{"git.sr.ht/~user/repo?ref=v1.0.0", "git::https://git.sr.ht/~user/repo?ref=v1.0.0"},
+		{"git@git.sr.ht:~user/repo", "git::ssh://git@git.sr.ht/~user/repo"},
+		{"git@git.sr.ht:~user/repo?ref=v1.0.0", "git::ssh://git@git.sr.ht/~user/repo?ref=v1.0.0"},
+	}
+
+	pwd := "/pwd"
+	f := new(SourceHutDetector)
+	for i, tc := range cases {
+		output, ok, err := f.Detect(tc.Input, pwd)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !ok {
+			t.Fatal("not ok")
+		}
+
+		if output != tc.Output {
+			t.Fatalf("%d: bad: %#v", i, output)
+		}
+	}
+}
+
+func TestSourceHutDetector_bad(t *testing.T) {
+	cases := []string{
+		"git.sr.ht/~user",
+		"git.sr.ht/user/repo",
+		"git.sr.ht/~/repo",
+		"git.sr.ht/~user/repo/tree",
+		"git@git.sr.ht:user/repo",
+	}
+
+	f := new(SourceHutDetector)
+	for _, tc := range cases {
+		t.Run(tc, func(t *testing.T) {
+			_, ok, err := f.Detect(tc, "")
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !ok {
+				t.Fatal("should be ok")
+			}
+		})
+	}
+}
+
+func TestDetect_sourceHut(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"git.sr.ht/~user/repo//modules/vpc?ref=v1.0.0",
+			"git::https://git.sr.ht/~user/repo//modules/vpc?ref=v1.0.0",
+		},
+		{
+			"git@git.sr.ht:~user/repo//modules/vpc?ref=v1.0.0",
+			"git::ssh://git@git.sr.ht/~user/repo//modules/vpc?ref=v1.0.0",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
@@ -2,31 +2,77 @@ package getter
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"golang.org/x/net/idna"
 )
 
 // Note that we do not have an SSH-getter currently so this file serves
 // only to hold the detectSSH helper that is used by other detectors.
 
-// sshPattern matches SCP-like SSH patterns (user@host:path)
-var sshPattern = regexp.MustCompile("^(?:([^@]+)@)?([^:]+):/?(.+)$")
+// sshPattern matches SCP-like SSH patterns (user@host:path). A leading
+// slash in the path is kept since it means an absolute path on the server
+// rather than one relative to the user's home directory.
+var sshPattern = regexp.MustCompile("^(?:([^@]+)@)?([^:]+):(.+)$")
+
+// sshIPv6Pattern matches SCP-like SSH patterns with a bracketed IPv6 host
+// (user@[host]:path). Since the host is bracketed, a numeric first path
+// segment is taken as a port (user@[host]:port/path).
+var sshIPv6Pattern = regexp.MustCompile(`^(?:([^@]+)@)?\[([^\]]+)\]:(?:([0-9]+)/)?(.+)$`)
+
+// sshPortPattern matches the variant of the SCP-like pattern with a port
+// between the host and the path (user@host:port:path), as emitted by some
+// SSH wrappers. The user is required so that other strings with colons
+// aren't mistaken for it. An empty port, as in "user@host::path", is
+// matched so that it can be rejected.
+var sshPortPattern = regexp.MustCompile(`^([^@]+)@([^:\[\]/]+):([0-9]*):(.*)$`)
 
 // detectSSH determines if the src string matches an SSH-like URL and
 // converts it into a net.URL compatible string. This returns nil if the
 // string doesn't match the SSH pattern.
 //
+// A port may be given between the host and the path, as in
+// "git@host:2222:org/repo", which is told apart from the usual form by the
+// second colon after a number.
+//
+// The path is kept exactly as given. In particular, a ".git" suffix is
+// neither appended nor removed, so "git@host:org/repo" stays "org/repo",
+// and a leading "~" for the home directory on the server, as in
+// "git@host:~/repo.git", is left for the server to expand.
+//
 // This function is tested indirectly via detect_git_test.go
 func detectSSH(src string) (*url.URL, error) {
-	matched := sshPattern.FindStringSubmatch(src)
-	if matched == nil {
+	var user, host, path string
+	if matched := sshIPv6Pattern.FindStringSubmatch(src); matched != nil {
+		user = matched[1]
+		host = "[" + matched[2] + "]"
+		if matched[3] != "" {
+			host += ":" + matched[3]
+		}
+		path = matched[4]
+	} else if matched := sshPortPattern.FindStringSubmatch(src); matched != nil {
+		port, err := strconv.Atoi(matched[3])
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %q in SSH address: %s", matched[3], src)
+		}
+		if matched[4] == "" {
+			return nil, fmt.Errorf("missing path in SSH address: %s", src)
+		}
+		user = matched[1]
+		host = matched[2] + ":" + matched[3]
+		path = matched[4]
+	} else if matched := sshPattern.FindStringSubmatch(src); matched != nil {
+		user = matched[1]
+		host = matched[2]
+		path = matched[3]
+	} else {
 		return nil, nil
 	}
 
-	user := matched[1]
-	host := matched[2]
-	path := matched[3]
 	qidx := strings.Index(path, "?")
 	if qidx == -1 {
 		qidx = len(path)
@@ -35,6 +81,11 @@ func detectSSH(src string) (*url.URL, error) {
 	var u url.URL
 	u.Scheme = "ssh"
 	u.User = url.User(user)
+	if idx := strings.Index(user, ":"); idx > -1 {
+		// Keep a password, as in "user:token@host:path", rather than
+		// folding it into the user name.
+		u.User = url.UserPassword(user[:idx], user[idx+1:])
+	}
 	u.Host = host
 	u.Path = path[0:qidx]
 	if qidx < len(path) {
@@ -47,3 +98,50 @@ func detectSSH(src string) (*url.URL, error) {
 
 	return &u, nil
 }
+
+// normalizeIDNHost converts an internationalized host name in u to its
+// ASCII (punycode) form. Any port is preserved.
+func normalizeIDNHost(u *url.URL) error {
+	host := u.Hostname()
+	if net.ParseIP(host) != nil {
+		// IP literals have nothing to normalize.
+		return nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return fmt.Errorf("error normalizing host %q: %s", host, err)
+	}
+
+	if port := u.Port(); port != "" {
+		ascii += ":" + port
+	}
+	u.Host = ascii
+	return nil
+}
+
+// isSCPLike reports whether src is an SCP-like address rather than a URL.
+func isSCPLike(src string) bool {
+	if _, err := url.Parse(src); err == nil {
+		return false
+	}
+
+	u, err := detectSSH(src)
+	return err == nil && u != nil
+}
+
+// fmtSCP formats an SSH URL in the SCP-like form user@host:path. The URL
+// must not have a port or password since the SCP-like form can't carry
+// them unambiguously.
+func fmtSCP(u *url.URL) string {
+	var result string
+	if u.User != nil {
+		result = u.User.String() + "@"
+	}
+	result += u.Host + ":" + u.Path
+	if u.RawQuery != "" {
+		result += "?" + u.RawQuery
+	}
+
+	return result
+}
@@ -0,0 +1,16 @@
+package getter
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// validateStdinSource checks that a "stdin://" pseudo-source, which stands
+// for content piped to the process, has no host or path. Query parameters,
+// such as "archive", are allowed.
+func validateStdinSource(u *url.URL) error {
+	if u.Host != "" || u.Path != "" || u.Opaque != "" || u.User != nil {
+		return fmt.Errorf("stdin sources must not have a host or path: %s", u)
+	}
+	return nil
+}
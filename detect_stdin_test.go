@@ -0,0 +1,31 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestDetect_stdin(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+		Err    bool
+	}{
+		{"stdin://", "stdin://", false},
+		{"stdin://?archive=tar.gz", "stdin://?archive=tar.gz", false},
+		{"stdin://extra", "", true},
+		{"stdin:///extra", "", true},
+		{"stdin://user@", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", Detectors)
+			if err != nil != tc.Err {
+				t.Fatalf("bad err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
@@ -1,7 +1,13 @@
 package getter
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -14,6 +20,34 @@ func TestDetect(t *testing.T) {
 	}{
 		{"./foo", "/foo", "file:///foo/foo", false},
 		{"git::./foo", "/foo", "git::file:///foo/foo", false},
+		{"hg::./foo", "/foo", "hg::file:///foo/foo", false},
+		{"  Git::./Foo", "/foo", "git::file:///foo/Foo", false},
+		{"git::git::./foo", "/foo", "git::file:///foo/foo", false},
+		{"file::./foo", "/foo", "file:///foo/foo", false},
+		{
+			"git+https://github.com/hashicorp/foo.git//bar?ref=v1",
+			"",
+			"git::https://github.com/hashicorp/foo.git//bar?ref=v1",
+			false,
+		},
+		{
+			"git+ssh://git@github.com/hashicorp/foo.git",
+			"",
+			"git::ssh://git@github.com/hashicorp/foo.git",
+			false,
+		},
+		{"git+file:///foo/bar", "", "git::file:///foo/bar", false},
+		{"git+foo://example.com/bar", "", "git+foo://example.com/bar", false},
+		{"file::foo//bar", "/foo", "file:///foo/foo//bar", false},
+		{"file::foo?baz=qux", "/foo", "file:///foo/foo?baz=qux", false},
+		{"file::./foo", "", "", true},
+		{"hg::git::./foo", "/foo", "hg::file:///foo/foo", false},
+		{
+			"git::git::https://github.com/hashicorp/consul.git",
+			"",
+			"git::https://github.com/hashicorp/consul.git",
+			false,
+		},
 		{
 			"git::github.com/hashicorp/foo",
 			"",
@@ -32,6 +66,18 @@ func TestDetect(t *testing.T) {
 			"git::https://github.com/hashicorp/foo.git//bar",
 			false,
 		},
+		{
+			"github.com/hashicorp/foo//path/to/file.yaml?ref=v1",
+			"",
+			"git::https://github.com/hashicorp/foo.git//path/to/file.yaml?ref=v1",
+			false,
+		},
+		{
+			"git@github.com:hashicorp/foo.git//path/to/file.yaml?ref=v1",
+			"",
+			"git::ssh://git@github.com/hashicorp/foo.git//path/to/file.yaml?ref=v1",
+			false,
+		},
 		{
 			"git::https://github.com/hashicorp/consul.git",
 			"",
@@ -50,6 +96,18 @@ func TestDetect(t *testing.T) {
 			"git::https://person@someothergit.com/foo/bar",
 			false,
 		},
+		{
+			"//host/path",
+			"/foo",
+			"file:////host/path",
+			false,
+		},
+		{
+			"//host/path//bar",
+			"/foo",
+			"file:////host/path//bar",
+			false,
+		},
 		{
 			"./foo/archive//*",
 			"/bar",
@@ -76,6 +134,18 @@ func TestDetect(t *testing.T) {
 			"git::ssh://git@my.custom.git/dir1/dir2",
 			false,
 		},
+		{
+			"hg@my.custom.hg:dir1/dir2",
+			"/foo",
+			"hg::ssh://hg@my.custom.hg/dir1/dir2",
+			false,
+		},
+		{
+			"hg::hg@my.custom.hg:dir1/dir2",
+			"",
+			"hg::ssh://hg@my.custom.hg/dir1/dir2",
+			false,
+		},
 	}
 
 	for i, tc := range cases {
@@ -90,3 +160,955 @@ func TestDetect(t *testing.T) {
 		})
 	}
 }
+
+type ctxTestDetector struct {
+	ctx context.Context
+}
+
+func (d *ctxTestDetector) Detect(src, pwd string) (string, bool, error) {
+	return d.DetectContext(context.Background(), src, pwd)
+}
+
+func (d *ctxTestDetector) DetectContext(ctx context.Context, src, _ string) (string, bool, error) {
+	d.ctx = ctx
+	return "https://example.com/" + src, true, nil
+}
+
+func TestDetectContext(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	d := new(ctxTestDetector)
+	output, err := DetectContext(ctx, "foo", "", []Detector{d})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if output != "https://example.com/foo" {
+		t.Fatalf("bad output: %s", output)
+	}
+	if d.ctx == nil || d.ctx.Value(ctxKey{}) != "value" {
+		t.Fatal("detector did not receive the context")
+	}
+
+	// Detectors that don't implement ContextDetector are still called.
+	output, err = DetectContext(ctx, "./foo", "/foo", []Detector{new(FileDetector)})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if output != "file:///foo/foo" {
+		t.Fatalf("bad output: %s", output)
+	}
+}
+
+func TestDetectContext_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	d := new(ctxTestDetector)
+	_, err := DetectContext(ctx, "foo", "", []Detector{d})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+	if d.ctx != nil {
+		t.Fatal("detector should not have been called")
+	}
+}
+
+func TestDetectSplit(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Pwd    string
+		Output DetectResult
+	}{
+		{
+			"./foo//bar?baz=qux",
+			"/foo",
+			DetectResult{
+				Source: "file:///foo/foo",
+				Subdir: "bar",
+				Query:  "baz=qux",
+			},
+		},
+		{
+			"github.com/hashicorp/foo/bar",
+			"",
+			DetectResult{
+				Force:  "git",
+				Source: "https://github.com/hashicorp/foo.git",
+				Subdir: "bar",
+			},
+		},
+		{
+			"github.com/hashicorp/foo//path/to/file.yaml?ref=v1",
+			"",
+			DetectResult{
+				Force:  "git",
+				Source: "https://github.com/hashicorp/foo.git",
+				Subdir: "path/to/file.yaml",
+				Query:  "ref=v1",
+			},
+		},
+		{
+			"git::https://github.com/hashicorp/consul.git//api?ref=v1",
+			"",
+			DetectResult{
+				Force:  "git",
+				Source: "https://github.com/hashicorp/consul.git",
+				Subdir: "api",
+				Query:  "ref=v1",
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d %s", i, tc.Input), func(t *testing.T) {
+			r, err := DetectSplit(context.Background(), tc.Input, tc.Pwd, Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if *r != tc.Output {
+				t.Fatalf("bad result: %#v\nexpected: %#v", *r, tc.Output)
+			}
+
+			output, err := Detect(tc.Input, tc.Pwd, Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if r.String() != output {
+				t.Fatalf("bad string: %s\nexpected: %s", r.String(), output)
+			}
+		})
+	}
+}
+
+// TestDetect_gitHubSubdir checks that the subdir of GitHub shorthand is
+// split off before the GitHubDetector runs and put back on its result,
+// through the full detection rather than the detector alone.
+func TestDetect_gitHubSubdir(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Pwd    string
+		Output DetectResult
+	}{
+		{
+			"github.com/org/repo//modules/vpc?ref=v2",
+			"",
+			DetectResult{
+				Force:  "git",
+				Source: "https://github.com/org/repo.git",
+				Subdir: "modules/vpc",
+				Query:  "ref=v2",
+			},
+		},
+		{
+			"github.com/org/repo//modules/vpc?ref=v2",
+			"/pwd",
+			DetectResult{
+				Force:  "git",
+				Source: "https://github.com/org/repo.git",
+				Subdir: "modules/vpc",
+				Query:  "ref=v2",
+			},
+		},
+		{
+			"github.com/org/repo.git//modules/vpc?ref=v2",
+			"",
+			DetectResult{
+				Force:  "git",
+				Source: "https://github.com/org/repo.git",
+				Subdir: "modules/vpc",
+				Query:  "ref=v2",
+			},
+		},
+		{
+			"git::github.com/org/repo//modules/vpc?ref=v2",
+			"",
+			DetectResult{
+				Force:  "git",
+				Source: "https://github.com/org/repo.git",
+				Subdir: "modules/vpc",
+				Query:  "ref=v2",
+			},
+		},
+		{
+			"github.com/org/repo//modules/vpc/nested?ref=v2&depth=1",
+			"",
+			DetectResult{
+				Force:  "git",
+				Source: "https://github.com/org/repo.git",
+				Subdir: "modules/vpc/nested",
+				Query:  "ref=v2&depth=1",
+			},
+		},
+		{
+			"github.com/org/repo//modules/vpc",
+			"",
+			DetectResult{
+				Force:  "git",
+				Source: "https://github.com/org/repo.git",
+				Subdir: "modules/vpc",
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d %s", i, tc.Input), func(t *testing.T) {
+			for _, ds := range [][]Detector{Detectors, {new(GitHubDetector)}} {
+				r, err := DetectSplit(context.Background(), tc.Input, tc.Pwd, ds)
+				if err != nil {
+					t.Fatalf("err: %s", err)
+				}
+				if *r != tc.Output {
+					t.Fatalf("bad result: %#v\nexpected: %#v", *r, tc.Output)
+				}
+
+				output, err := Detect(tc.Input, tc.Pwd, ds)
+				if err != nil {
+					t.Fatalf("err: %s", err)
+				}
+				if expected := tc.Output.String(); output != expected {
+					t.Fatalf("bad: %s\nexpected: %s", output, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestDetect_refWithSlashes(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output DetectResult
+	}{
+		{
+			"git@host:org/repo.git//modules/net?ref=release/1.2",
+			DetectResult{
+				Force:  "git",
+				Source: "ssh://git@host/org/repo.git",
+				Subdir: "modules/net",
+				Query:  "ref=release/1.2",
+			},
+		},
+		{
+			"git::https://host/org/repo.git//modules/net?ref=release/1.2",
+			DetectResult{
+				Force:  "git",
+				Source: "https://host/org/repo.git",
+				Subdir: "modules/net",
+				Query:  "ref=release/1.2",
+			},
+		},
+		{
+			"github.com/org/repo//modules/net?ref=release/1.2",
+			DetectResult{
+				Force:  "git",
+				Source: "https://github.com/org/repo.git",
+				Subdir: "modules/net",
+				Query:  "ref=release/1.2",
+			},
+		},
+		{
+			"git@host:org/repo.git?ref=release//1.2",
+			DetectResult{
+				Force:  "git",
+				Source: "ssh://git@host/org/repo.git",
+				Query:  "ref=release//1.2",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			r, err := DetectSplit(context.Background(), tc.Input, "/pwd", Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if *r != tc.Output {
+				t.Fatalf("bad result: %#v\nexpected: %#v", *r, tc.Output)
+			}
+		})
+	}
+}
+
+func TestDefaultDetectorsExcept(t *testing.T) {
+	ds := DefaultDetectorsExcept("codecommit", "bitbucket", "s3", "gcs")
+
+	var names []string
+	for _, d := range ds {
+		names = append(names, detectorName(d))
+	}
+
+	expected := []string{"github", "gitlab", "sourcehut", "huggingface", "launchpad", "git", "hg", "file"}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Fatalf("bad detectors: %v\nexpected: %v", names, expected)
+	}
+
+	if len(DefaultDetectorsExcept()) != len(Detectors) {
+		t.Fatal("expected all default detectors")
+	}
+}
+
+func TestDetectPreferring(t *testing.T) {
+	const input = "github.com/hashicorp/foo"
+
+	output, err := Detect(input, "/pwd", Detectors)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if output != "git::https://github.com/hashicorp/foo.git" {
+		t.Fatalf("bad output: %s", output)
+	}
+
+	before := fmt.Sprint(Detectors)
+	output, err = DetectPreferring(input, "/pwd", []Detector{new(FileDetector)}, Detectors)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if output != "file:///pwd/github.com/hashicorp/foo" {
+		t.Fatalf("bad output: %s", output)
+	}
+	if fmt.Sprint(Detectors) != before {
+		t.Fatal("Detectors should not be modified")
+	}
+}
+
+func TestDetect_noMatch(t *testing.T) {
+	_, err := Detect("foo", "", []Detector{new(GitHubDetector)})
+	if !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("expected ErrNoMatch, got: %v", err)
+	}
+	if err.Error() != "invalid source string: foo" {
+		t.Fatalf("bad error: %s", err)
+	}
+
+	var noMatch *NoMatchError
+	if !errors.As(err, &noMatch) || noMatch.Src != "foo" {
+		t.Fatalf("expected NoMatchError for foo, got: %#v", err)
+	}
+
+	// Errors from detectors are not ErrNoMatch.
+	_, err = Detect("./foo", "", []Detector{new(FileDetector)})
+	if err == nil || errors.Is(err, ErrNoMatch) {
+		t.Fatalf("expected a detector error, got: %v", err)
+	}
+}
+
+// defaultsTestDetector is a Detector that adds default query parameters
+// to any source.
+type defaultsTestDetector struct{}
+
+func (d *defaultsTestDetector) Detect(src, _ string) (string, bool, error) {
+	src, _ = splitQuery(src)
+	return "git::https://example.com/" + src + "?ref=main&depth=1", true, nil
+}
+
+func TestDetectExplain(t *testing.T) {
+	cases := []struct {
+		Input   string
+		Reasons []string
+	}{
+		{
+			"./foo",
+			[]string{
+				"git: ssh detection yielded nil URL",
+				"github: did not match",
+			},
+		},
+		{
+			"my_host:org/repo",
+			[]string{
+				"git: ssh address has no git user",
+				"github: did not match",
+			},
+		},
+		{
+			"deploy@host.com:org/repo",
+			[]string{
+				`git: user "deploy" is not git`,
+				"github: did not match",
+			},
+		},
+	}
+
+	ds := []Detector{new(GitDetector), new(GitHubDetector)}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			_, reasons, err := DetectExplain(tc.Input, "/pwd", ds)
+			if !errors.Is(err, ErrNoMatch) {
+				t.Fatalf("expected no match, got: %v", err)
+			}
+			if !reflect.DeepEqual(reasons, tc.Reasons) {
+				t.Fatalf("bad reasons: %#v\nexpected: %#v", reasons, tc.Reasons)
+			}
+		})
+	}
+}
+
+func TestDetectExplain_match(t *testing.T) {
+	ds := []Detector{new(GitHubDetector), new(GitDetector), new(FileDetector)}
+	output, reasons, err := DetectExplain("./foo", "/pwd", ds)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if output != "file:///pwd/foo" {
+		t.Fatalf("bad: %s", output)
+	}
+	expected := []string{
+		"github: did not match",
+		"git: ssh detection yielded nil URL",
+	}
+	if !reflect.DeepEqual(reasons, expected) {
+		t.Fatalf("bad reasons: %#v\nexpected: %#v", reasons, expected)
+	}
+}
+
+func TestDetect_queryMerge(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"foo", "git::https://example.com/foo?ref=main&depth=1"},
+		{"foo?ref=v1", "git::https://example.com/foo?depth=1&ref=v1"},
+		{"foo//mod?ref=v1", "git::https://example.com/foo//mod?depth=1&ref=v1"},
+		{
+			"foo//mod?ref=v1&depth=5&sshkey=a",
+			"git::https://example.com/foo//mod?ref=v1&depth=5&sshkey=a",
+		},
+	}
+
+	ds := []Detector{new(defaultsTestDetector)}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "", ds)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestDetectWithForce(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Force  string
+		Output string
+		Err    string
+	}{
+		{"./foo", "git", "git::file:///pwd/foo", ""},
+		{"git::./foo", "git", "git::file:///pwd/foo", ""},
+		{"Git::./foo", "git", "git::file:///pwd/foo", ""},
+		{"./foo", "", "file:///pwd/foo", ""},
+		{"git@github.com:org/repo.git", "git", "git::ssh://git@github.com/org/repo.git", ""},
+		{"https://example.com/foo.tgz", "http", "http::https://example.com/foo.tgz", ""},
+		{"hg::./foo", "git", "", `forced getter "git" conflicts with "hg"`},
+		{"s3::https://s3.amazonaws.com/bucket/foo", "gcs", "", `forced getter "gcs" conflicts with "s3"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Force+" "+tc.Input, func(t *testing.T) {
+			output, err := DetectWithForce(tc.Input, tc.Force, "/pwd", Detectors)
+			if tc.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.Err) {
+					t.Fatalf("expected error %q, got: %v", tc.Err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+// errorTestDetector is a Detector that fails for any source, or panics if
+// panics is set.
+type errorTestDetector struct {
+	panics bool
+}
+
+func (d errorTestDetector) Detect(src, _ string) (string, bool, error) {
+	if d.panics {
+		panic("detector panicked")
+	}
+	return "", true, fmt.Errorf("bad source: %s", src)
+}
+
+func TestTryDetect(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Input  string
+		Ds     []Detector
+		Output string
+		Ok     bool
+	}{
+		{"match", "./foo", []Detector{new(FileDetector)}, "file:///pwd/foo", true},
+		{"no match", "foo", []Detector{new(GitHubDetector)}, "foo", false},
+		{"error", "foo", []Detector{errorTestDetector{}}, "foo", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			output, ok := TryDetect(tc.Input, "/pwd", tc.Ds)
+			if ok != tc.Ok {
+				t.Fatalf("bad ok: %v", ok)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+
+	t.Run("panic", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		TryDetect("foo", "/pwd", []Detector{errorTestDetector{panics: true}})
+	})
+}
+
+// tokenTestDetector is a ForceTokenDetector that claims the "custom" force
+// token.
+type tokenTestDetector struct{}
+
+func (d tokenTestDetector) ForceTokens() []string {
+	return []string{"custom"}
+}
+
+func (d tokenTestDetector) Detect(src, _ string) (string, bool, error) {
+	force, src := getForcedGetter(src)
+	if force != "custom" {
+		return "", false, nil
+	}
+	return "https://example.com/" + src, true, nil
+}
+
+func TestDetect_forceTokens(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Opts   []DetectOption
+		Output string
+		Err    string
+	}{
+		{"custom::foo", nil, "https://example.com/foo", ""},
+		{"git::./foo", nil, "git::file:///pwd/foo", ""},
+		{"hg::./foo", nil, "hg::file:///pwd/foo", ""},
+		{"s3::https://s3.amazonaws.com/bucket/foo", nil, "s3::https://s3.amazonaws.com/bucket/foo", ""},
+		{"gti::./foo", nil, "", `no detector handles force token "gti"`},
+		{"gti::https://example.com/foo.git", nil, "", `no detector handles force token "gti"`},
+		{"other::./foo", nil, "", `no detector handles force token "other"`},
+		{"other::./foo", []DetectOption{WithGetterTokens("other")}, "other::file:///pwd/foo", ""},
+		{"git::./foo", []DetectOption{WithGetterTokens("other")}, "", `no detector handles force token "git"`},
+	}
+
+	ds := []Detector{tokenTestDetector{}, new(FileDetector)}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", ds, tc.Opts...)
+			if tc.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.Err) {
+					t.Fatalf("expected error %q, got: %v", tc.Err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestDetect_fileURLAuthority(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"file:/abs/path", "file:///abs/path"},
+		{"file:/abs/path?archive=false", "file:///abs/path?archive=false"},
+		{"git::file:/abs/repo?ref=v1", "git::file:///abs/repo?ref=v1"},
+		{"file:/abs/repo//sub", "file:///abs/repo//sub"},
+		{"file://localhost/abs/path", "file:///abs/path"},
+		{"file://LOCALHOST/abs/path", "file:///abs/path"},
+		{"file:///abs/path", "file:///abs/path"},
+		{"git::file:///abs/repo", "git::file:///abs/repo"},
+
+		// Another host is a UNC share on Windows, so it is kept.
+		{"file://server/share/path", "file://server/share/path"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestDetect_emptyQueryFragment(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"git@host:org/repo.git?", "git::ssh://git@host/org/repo.git"},
+		{"git@host:org/repo.git#", "git::ssh://git@host/org/repo.git"},
+		{"git@host:org/repo.git?#", "git::ssh://git@host/org/repo.git"},
+		{"git::git@host:org/repo.git?", "git::ssh://git@host/org/repo.git"},
+		{"git@host:org/repo.git//sub?", "git::ssh://git@host/org/repo.git//sub"},
+		{"git@host:org/repo.git?ref=v1#", "git::ssh://git@host/org/repo.git?ref=v1"},
+		{"https://example.com/foo?", "https://example.com/foo"},
+		{"https://example.com/foo#", "https://example.com/foo"},
+		{"github.com/org/repo#", "git::https://github.com/org/repo.git"},
+		{"./foo#", "file:///pwd/foo"},
+
+		// A fragment that isn't empty is kept.
+		{"https://example.com/foo#bar", "https://example.com/foo#bar"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "/pwd", Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestDetect_forcedFileEscape(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{"git::./my repo", "git::file:///pwd/my%20repo"},
+		{"git::./my#repo?ref=v1", "git::file:///pwd/my%23repo?ref=v1"},
+		{"git::./100%/repo//sub", "git::file:///pwd/100%25/repo//sub"},
+		{"hg::./my repo", "hg::file:///pwd/my%20repo"},
+
+		// Unforced file paths are left as they were.
+		{"./my repo", "file:///pwd/my repo"},
+		{"file::./my repo", "file:///pwd/my repo"},
+	}
+
+	pwd := "/pwd"
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, pwd, Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+// TestDetect_forcedFileRoundTrip checks that detecting the output of a
+// file path forced to a getter, such as "git::file:///pwd/foo?ref=v1",
+// gives back the same output.
+func TestDetect_forcedFileRoundTrip(t *testing.T) {
+	cases := []string{
+		"git::./foo",
+		"git::./foo?ref=v1",
+		"git::./foo//sub?ref=v1",
+		"git::/abs/path?ref=v1",
+		"git::./my repo#1?ref=v1",
+		"git::git::./foo?ref=v1",
+		"hg::./foo",
+		"git::file:///abs/path?ref=v1",
+	}
+
+	for _, input := range cases {
+		t.Run(input, func(t *testing.T) {
+			first, err := DetectSplit(context.Background(), input, "/pwd", Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			output := first.String()
+			second, err := DetectSplit(context.Background(), output, "/pwd", Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if *second != *first {
+				t.Fatalf("not idempotent for %s\nfirst:  %#v\nsecond: %#v",
+					output, *first, *second)
+			}
+
+			// The pwd has no bearing on a source that is already a URL.
+			again, err := Detect(output, "/other", Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if again != output {
+				t.Fatalf("bad: %s\nexpected: %s", again, output)
+			}
+		})
+	}
+}
+
+func TestDetect_forcedFileQuery(t *testing.T) {
+	cases := []struct {
+		Input string
+		Query string
+	}{
+		{"git::./foo?ref=v1&depth=1", "ref=v1&depth=1"},
+		{"git::./foo?depth=1&ref=v1", "depth=1&ref=v1"},
+		{"git::./foo//sub?ref=v1&depth=1", "ref=v1&depth=1"},
+		{"git::./my repo?ref=feature%2Fx&sshkey=a%2Bb%3D%3D", "ref=feature%2Fx&sshkey=a%2Bb%3D%3D"},
+		{"git::./my repo//sub?ref=feature%2Fx&sshkey=a%2Bb%3D%3D", "ref=feature%2Fx&sshkey=a%2Bb%3D%3D"},
+		{"hg::./foo?rev=1&x=a%26b", "rev=1&x=a%26b"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			r, err := DetectSplit(context.Background(), tc.Input, "/pwd", Detectors)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			// The query is kept as given, in order and with its encoding.
+			if r.Query != tc.Query {
+				t.Fatalf("bad query: %s\nexpected: %s", r.Query, tc.Query)
+			}
+
+			got, err := url.ParseQuery(r.Query)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			expected, err := url.ParseQuery(tc.Query)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Fatalf("bad params: %v\nexpected: %v", got, expected)
+			}
+
+			// The parameters survive in the file URL as well.
+			u, err := url.Parse(r.Source + "?" + r.Query)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !reflect.DeepEqual(u.Query(), expected) {
+				t.Fatalf("bad URL params: %v\nexpected: %v", u.Query(), expected)
+			}
+		})
+	}
+}
+
+func TestDetect_relativePwd(t *testing.T) {
+	// Sources that don't need the pwd are detected with a relative one.
+	output, err := Detect("github.com/a/b", ".", Detectors)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if expected := "git::https://github.com/a/b.git"; output != expected {
+		t.Fatalf("bad: %s", output)
+	}
+
+	// A relative directory to resolve from can't be resolved against it.
+	ds := []Detector{new(ctxTestDetector)}
+	_, err = Detect("foo", "relative/pwd", ds, WithResolveFrom("modules"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if err.Error() != "relative directory to resolve from requires an absolute pwd: modules" {
+		t.Fatalf("bad error: %s", err)
+	}
+	if ds[0].(*ctxTestDetector).ctx != nil {
+		t.Fatal("detector should not be called")
+	}
+}
+
+// subdirTestDetector is a SubdirDetector that maps subdirs under "v1" to
+// subdirs under "modules".
+type subdirTestDetector struct{}
+
+func (d *subdirTestDetector) Detect(src, pwd string) (string, bool, error) {
+	result, _, ok, err := d.DetectSubdir(context.Background(), src, "", pwd)
+	return result, ok, err
+}
+
+func (d *subdirTestDetector) DetectSubdir(_ context.Context, src, subDir, _ string) (string, string, bool, error) {
+	if !strings.HasPrefix(src, "mono.example.com/") {
+		return "", "", false, nil
+	}
+	if strings.HasPrefix(subDir, "v1/") {
+		subDir = "modules/" + strings.TrimPrefix(subDir, "v1/")
+	}
+	return "git::https://" + src, subDir, true, nil
+}
+
+func TestDetect_subdirDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output string
+	}{
+		{
+			"mono.example.com/repo.git//v1/mod",
+			"git::https://mono.example.com/repo.git//modules/mod",
+		},
+		{
+			"mono.example.com/repo.git//v1/mod?ref=v1.0.0",
+			"git::https://mono.example.com/repo.git//modules/mod?ref=v1.0.0",
+		},
+		{
+			"mono.example.com/repo.git//other",
+			"git::https://mono.example.com/repo.git//other",
+		},
+		{
+			"mono.example.com/repo.git",
+			"git::https://mono.example.com/repo.git",
+		},
+		// Other detectors keep the subdir from the source.
+		{
+			"github.com/hashicorp/foo//v1/mod",
+			"git::https://github.com/hashicorp/foo.git//v1/mod",
+		},
+	}
+
+	ds := []Detector{new(subdirTestDetector), new(GitHubDetector)}
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			output, err := Detect(tc.Input, "", ds)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if output != tc.Output {
+				t.Fatalf("bad output: %s\nexpected: %s", output, tc.Output)
+			}
+		})
+	}
+}
+
+func TestRegisterDetector(t *testing.T) {
+	defer ResetDetectors()
+
+	first := new(ctxTestDetector)
+	last := new(defaultsTestDetector)
+	RegisterDetector(last)
+	if err := RegisterDetectorAt(0, first); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ds := registeredDetectors()
+	if len(ds) != len(defaultDetectors())+2 || ds[0] != first || ds[len(ds)-1] != last {
+		t.Fatalf("bad detectors: %#v", ds)
+	}
+
+	if err := RegisterDetectorAt(len(ds)+1, first); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := RegisterDetectorAt(-1, first); err == nil {
+		t.Fatal("expected error")
+	}
+
+	ResetDetectors()
+	if len(registeredDetectors()) != len(defaultDetectors()) {
+		t.Fatalf("bad detectors: %#v", registeredDetectors())
+	}
+}
+
+func TestMoveDetector(t *testing.T) {
+	defer ResetDetectors()
+
+	names := DetectorNames()
+	if names[0] != "github" || names[len(names)-1] != "file" {
+		t.Fatalf("bad names: %v", names)
+	}
+
+	custom := new(ctxTestDetector)
+	RegisterDetector(custom)
+	label := detectorLabel(custom)
+
+	if err := MoveDetectorBefore(label, "git"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	names = DetectorNames()
+	if i := indexDetector(registeredDetectors(), "git"); i < 1 || names[i-1] != label {
+		t.Fatalf("expected %s before git: %v", label, names)
+	}
+	if len(names) != len(defaultDetectors())+1 {
+		t.Fatalf("bad names: %v", names)
+	}
+
+	if err := MoveDetectorToFront("file"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	names = DetectorNames()
+	if names[0] != "file" || names[1] != "github" {
+		t.Fatalf("expected file first: %v", names)
+	}
+
+	// Moving a detector before itself leaves it where it is.
+	if err := MoveDetectorBefore("file", "file"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if DetectorNames()[0] != "file" {
+		t.Fatalf("bad names: %v", DetectorNames())
+	}
+
+	if err := MoveDetectorBefore("nope", "git"); err == nil {
+		t.Fatal("expected error for an unknown detector")
+	}
+	if err := MoveDetectorBefore("git", "nope"); err == nil {
+		t.Fatal("expected error for an unknown detector")
+	}
+	if err := MoveDetectorToFront("nope"); err == nil {
+		t.Fatal("expected error for an unknown detector")
+	}
+}
+
+func TestRegisterDetector_concurrent(t *testing.T) {
+	defer ResetDetectors()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				RegisterDetector(new(GitHubDetector))
+				if err := RegisterDetectorAt(0, new(GitLabDetector)); err != nil {
+					t.Errorf("err: %s", err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				output, err := Detect("github.com/hashicorp/foo", "", registeredDetectors())
+				if err != nil {
+					t.Errorf("err: %s", err)
+					return
+				}
+				if output != "git::https://github.com/hashicorp/foo.git" {
+					t.Errorf("bad output: %s", output)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(registeredDetectors()); n != len(defaultDetectors())+400 {
+		t.Fatalf("bad number of detectors: %d", n)
+	}
+}
@@ -0,0 +1,60 @@
+package getter
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// VendorDetector implements Detector to detect relative file paths that
+// resolve inside a vendor directory, such as "./vendor/modules/vpc", and
+// turn them into file URLs anchored at that directory.
+//
+// Unlike FileDetector, symlinks in pwd are not resolved, so the same
+// vendored source is detected as the same URL on every machine that uses
+// the same VendorRoot. Paths outside VendorRoot are not detected, and are
+// left to the detectors that follow, such as FileDetector.
+//
+// This detector isn't in the default Detectors since it needs a
+// VendorRoot to be useful.
+type VendorDetector struct {
+	// VendorRoot is the vendor directory. A relative VendorRoot is
+	// resolved against pwd. If this is empty, nothing is detected.
+	VendorRoot string
+}
+
+func (d *VendorDetector) Name() string {
+	return "vendor"
+}
+
+func (d *VendorDetector) Detect(src, pwd string) (string, bool, error) {
+	if len(src) == 0 || d.VendorRoot == "" {
+		return "", false, nil
+	}
+
+	srcPath, query := splitQuery(src)
+	if filepath.IsAbs(srcPath) || strings.HasPrefix(srcPath, "~") {
+		return "", false, nil
+	}
+
+	root := d.VendorRoot
+	if !filepath.IsAbs(root) {
+		if pwd == "" {
+			return "", false, nil
+		}
+		root = filepath.Join(pwd, root)
+	}
+	root = filepath.Clean(root)
+
+	rel, err := filepath.Rel(root, filepath.Join(pwd, srcPath))
+	if err != nil || rel == "." || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false, nil
+	}
+
+	result := fmtFileURL(filepath.Join(root, rel))
+	if query != "" {
+		result += "?" + query
+	}
+
+	return result, true, nil
+}
@@ -0,0 +1,72 @@
+package getter
+
+import (
+	"testing"
+)
+
+func TestVendorDetector(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Pwd    string
+		Root   string
+		Output string
+	}{
+		{"./vendor/vpc", "/pwd", "/pwd/vendor", "file:///pwd/vendor/vpc"},
+		{"vendor/vpc?archive=zip", "/pwd", "/pwd/vendor", "file:///pwd/vendor/vpc?archive=zip"},
+		{"../vendor/vpc", "/pwd/app", "/pwd/vendor", "file:///pwd/vendor/vpc"},
+		{"./vendor/a/../vpc", "/pwd", "vendor", "file:///pwd/vendor/vpc"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			f := &VendorDetector{VendorRoot: tc.Root}
+			output, ok, err := f.Detect(tc.Input, tc.Pwd)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !ok {
+				t.Fatal("not ok")
+			}
+			if output != tc.Output {
+				t.Fatalf("bad: %#v", output)
+			}
+		})
+	}
+}
+
+func TestVendorDetector_outOfRoot(t *testing.T) {
+	cases := []struct {
+		Input string
+		Root  string
+	}{
+		{"./modules/vpc", "/pwd/vendor"},
+		{"./vendor", "/pwd/vendor"},
+		{"./vendor/../vpc", "/pwd/vendor"},
+		{"./vendor-other/vpc", "/pwd/vendor"},
+		{"/pwd/vendor/vpc", "/pwd/vendor"},
+		{"./vendor/vpc", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			f := &VendorDetector{VendorRoot: tc.Root}
+			_, ok, err := f.Detect(tc.Input, "/pwd")
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if ok {
+				t.Fatal("should not be ok")
+			}
+		})
+	}
+
+	// Sources outside the root are left to the file detector.
+	ds := []Detector{&VendorDetector{VendorRoot: "/pwd/vendor"}, new(FileDetector)}
+	output, err := Detect("./modules/vpc", "/pwd", ds)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if output != "file:///pwd/modules/vpc" {
+		t.Fatalf("bad: %#v", output)
+	}
+}
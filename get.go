@@ -17,6 +17,7 @@ import (
 	"net/url"
 	"os/exec"
 	"regexp"
+	"strings"
 	"syscall"
 
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
@@ -139,12 +140,29 @@ func getRunCommand(cmd *exec.Cmd) error {
 	return fmt.Errorf("error running %s: %s", cmd.Path, buf.String())
 }
 
+// SplitForce splits a source into its forced getter, such as "git" in
+// "git::https://example.com/repo.git", and the rest of the source, the
+// same way Detect and Client do. The forced getter is empty if there is
+// none.
+//
+// Surrounding whitespace is trimmed and the forced getter is lowercased.
+// Only the outermost forced getter is split off, and the rest, including
+// any subdir and query, is returned as-is. Use SourceDirSubdir to split
+// off the subdir.
+func SplitForce(src string) (force, rest string) {
+	return getForcedGetter(src)
+}
+
 // getForcedGetter takes a source and returns the tuple of the forced
 // getter and the raw URL (without the force syntax).
+//
+// Surrounding whitespace is trimmed and the forced getter is lowercased,
+// so " Git::foo" is the same as "git::foo".
 func getForcedGetter(src string) (string, string) {
 	var forced string
+	src = strings.TrimSpace(src)
 	if ms := forcedRegexp.FindStringSubmatch(src); ms != nil {
-		forced = ms[1]
+		forced = strings.ToLower(ms[1])
 		src = ms[2]
 	}
 
@@ -10,6 +10,7 @@ import (
 
 	"cloud.google.com/go/storage"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
 // GCSGetter is a Getter implementation that will download a module from
@@ -27,7 +28,7 @@ func (g *GCSGetter) ClientMode(u *url.URL) (ClientMode, error) {
 		return 0, err
 	}
 
-	client, err := storage.NewClient(ctx)
+	client, err := g.newClient(ctx, u)
 	if err != nil {
 		return 0, err
 	}
@@ -81,7 +82,7 @@ func (g *GCSGetter) Get(dst string, u *url.URL) error {
 		return err
 	}
 
-	client, err := storage.NewClient(ctx)
+	client, err := g.newClient(ctx, u)
 	if err != nil {
 		return err
 	}
@@ -123,7 +124,7 @@ func (g *GCSGetter) GetFile(dst string, u *url.URL) error {
 		return err
 	}
 
-	client, err := storage.NewClient(ctx)
+	client, err := g.newClient(ctx, u)
 	if err != nil {
 		return err
 	}
@@ -152,6 +153,17 @@ func (g *GCSGetter) getObject(ctx context.Context, client *storage.Client, dst,
 	return err
 }
 
+// newClient returns a storage client for u. Public buckets can be fetched
+// without any credentials by setting the "anon=true" parameter.
+func (g *GCSGetter) newClient(ctx context.Context, u *url.URL) (*storage.Client, error) {
+	var opts []option.ClientOption
+	if u.Query().Get("anon") == "true" {
+		opts = append(opts, option.WithoutAuthentication())
+	}
+
+	return storage.NewClient(ctx, opts...)
+}
+
 func (g *GCSGetter) parseURL(u *url.URL) (bucket, path string, err error) {
 	if strings.Contains(u.Host, "googleapis.com") {
 		hostParts := strings.Split(u.Host, ".")
@@ -434,8 +434,10 @@ func TestGitGetter_sshSCPStyleInvalidScheme(t *testing.T) {
 
 	// This test exercises the combination of the git detector and the
 	// git getter, to make sure that together they make scp-style URLs work.
+	// Only sources forced to git are read as SCP-like, so without the force
+	// "hashicorp" is an invalid port.
 	client := &Client{
-		Src: "git::ssh://git@github.com:hashicorp/test-private-repo?sshkey=" + encodedKey,
+		Src: "ssh://git@github.com:hashicorp/test-private-repo?sshkey=" + encodedKey,
 		Dst: dst,
 		Pwd: ".",
 
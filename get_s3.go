@@ -271,5 +271,10 @@ func (g *S3Getter) parseUrl(u *url.URL) (region, bucket, path, version string, c
 		)
 	}
 
+	// Public buckets can be fetched without any credentials.
+	if u.Query().Get("anon") == "true" {
+		creds = credentials.AnonymousCredentials
+	}
+
 	return
 }
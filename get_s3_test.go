@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 )
 
 func init() {
@@ -251,3 +252,19 @@ func TestS3Getter_Url(t *testing.T) {
 		})
 	}
 }
+
+func TestS3Getter_UrlAnonymous(t *testing.T) {
+	g := new(S3Getter)
+	u, err := url.Parse("https://s3.amazonaws.com/bucket/foo?anon=true")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	_, _, _, _, creds, err := g.parseUrl(u)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if creds != credentials.AnonymousCredentials {
+		t.Fatalf("expected anonymous credentials, got: %#v", creds)
+	}
+}
@@ -58,6 +58,14 @@ var winParseTests = []parseTest{
 		str:    `file://C:/`,
 		err:    false,
 	},
+	{
+		rawURL: `file://server/share/repo`,
+		scheme: `file`,
+		host:   ``,
+		path:   `//server/share/repo`,
+		str:    `file:////server/share/repo`,
+		err:    false,
+	},
 }
 
 func TestParse(t *testing.T) {
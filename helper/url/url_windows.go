@@ -28,6 +28,11 @@ func parse(rawURL string) (*url.URL, error) {
 		// letter has been parsed into the URL Host.
 		u.Path = fmt.Sprintf("%s%s", u.Host, u.Path)
 		u.Host = ""
+	} else if u.Host != "" && u.Scheme == "file" {
+		// A file URL with a host refers to a UNC path, so fold the host
+		// back into the path as //server/share/path.
+		u.Path = fmt.Sprintf("//%s%s", u.Host, u.Path)
+		u.Host = ""
 	}
 
 	// Remove leading slash for absolute file paths.
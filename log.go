@@ -0,0 +1,74 @@
+package getter
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Logger receives the diagnostic messages of the package, such as which
+// detector matched a source.
+type Logger interface {
+	// Debugf logs a message that is only useful when debugging.
+	Debugf(format string, args ...interface{})
+
+	// Warnf logs a message about something that is likely a mistake but
+	// that doesn't stop the operation.
+	Warnf(format string, args ...interface{})
+}
+
+var (
+	loggerLock sync.RWMutex
+	logger     = defaultLogger()
+)
+
+// SetLogger sets the Logger that the package logs to. A nil Logger
+// discards all messages.
+//
+// By default messages are discarded, unless the GO_GETTER_DEBUG
+// environment variable is set to a true value such as "1", in which case
+// they are written to stderr.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	logger = l
+}
+
+// getLogger returns the Logger set with SetLogger.
+func getLogger() Logger {
+	loggerLock.RLock()
+	defer loggerLock.RUnlock()
+	return logger
+}
+
+// defaultLogger returns the Logger to use if none is set.
+func defaultLogger() Logger {
+	if debug, _ := strconv.ParseBool(os.Getenv("GO_GETTER_DEBUG")); debug {
+		return &stdLogger{log.New(os.Stderr, "[go-getter] ", log.LstdFlags)}
+	}
+	return nopLogger{}
+}
+
+// nopLogger is a Logger that discards all messages.
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Warnf(string, ...interface{})  {}
+
+// stdLogger is a Logger that writes to a standard library logger.
+type stdLogger struct {
+	l *log.Logger
+}
+
+func (s *stdLogger) Debugf(format string, args ...interface{}) {
+	s.l.Printf("[DEBUG] "+format, args...)
+}
+
+func (s *stdLogger) Warnf(format string, args ...interface{}) {
+	s.l.Printf("[WARN] "+format, args...)
+}
@@ -0,0 +1,74 @@
+package getter
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// recordingLogger is a Logger that records all messages.
+type recordingLogger struct {
+	debug []string
+	warn  []string
+}
+
+func (r *recordingLogger) Debugf(format string, args ...interface{}) {
+	r.debug = append(r.debug, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingLogger) Warnf(format string, args ...interface{}) {
+	r.warn = append(r.warn, fmt.Sprintf(format, args...))
+}
+
+func TestSetLogger(t *testing.T) {
+	old := getLogger()
+	defer SetLogger(old)
+
+	l := new(recordingLogger)
+	SetLogger(l)
+
+	if _, err := Detect("github.com/hashicorp/foo", "", Detectors); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := `detector github detected "github.com/hashicorp/foo" as "git::https://github.com/hashicorp/foo.git"`
+	if len(l.debug) != 1 || l.debug[0] != expected {
+		t.Fatalf("bad debug messages: %q", l.debug)
+	}
+
+	// A nil Logger discards messages.
+	SetLogger(nil)
+	if _, ok := getLogger().(nopLogger); !ok {
+		t.Fatalf("bad logger: %#v", getLogger())
+	}
+	if _, err := Detect("github.com/hashicorp/foo", "", Detectors); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(l.debug) != 1 {
+		t.Fatalf("bad debug messages: %q", l.debug)
+	}
+}
+
+func TestDefaultLogger(t *testing.T) {
+	cases := []struct {
+		Value string
+		Std   bool
+	}{
+		{"", false},
+		{"0", false},
+		{"false", false},
+		{"1", true},
+		{"true", true},
+	}
+
+	defer os.Setenv("GO_GETTER_DEBUG", os.Getenv("GO_GETTER_DEBUG"))
+	for _, tc := range cases {
+		t.Run(tc.Value, func(t *testing.T) {
+			os.Setenv("GO_GETTER_DEBUG", tc.Value)
+			_, std := defaultLogger().(*stdLogger)
+			if std != tc.Std {
+				t.Fatalf("bad logger: %#v", defaultLogger())
+			}
+		})
+	}
+}
@@ -9,10 +9,17 @@ import (
 // SourceDirSubdir takes a source URL and returns a tuple of the URL without
 // the subdir and the subdir.
 //
+// The subdir starts at the first "//" in the path, not counting the one in
+// "://" or a "//" that starts the path. A query after the subdir is moved
+// back onto the URL. Any forced getter, such as "git::", is treated as part
+// of the URL, so split it off first with SplitForce. This is the same
+// splitting that Detect and Client do.
+//
 // ex:
 //   dom.com/path/?q=p               => dom.com/path/?q=p, ""
 //   proto://dom.com/path//*?q=p     => proto://dom.com/path?q=p, "*"
 //   proto://dom.com/path//path2?q=p => proto://dom.com/path?q=p, "path2"
+//   //host/path//path2              => //host/path, "path2"
 //
 func SourceDirSubdir(src string) (string, string) {
 
@@ -29,6 +36,13 @@ func SourceDirSubdir(src string) (string, string) {
 		offset = idx + 3
 	}
 
+	// A "//" right at the start of the path is a POSIX network path such
+	// as "//host/path" (or "file:////host/path"), not a subdir with an
+	// empty source.
+	if strings.HasPrefix(src[offset:stop], "//") {
+		offset += 2
+	}
+
 	// First see if we even have an explicit subdir
 	idx := strings.Index(src[offset:stop], "//")
 	if idx == -1 {
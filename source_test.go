@@ -40,6 +40,18 @@ func TestSourceDirSubdir(t *testing.T) {
 			"file://foo//bar",
 			"file://foo", "bar",
 		},
+		{
+			"//host/path",
+			"//host/path", "",
+		},
+		{
+			"//host/path//bar?baz=qux",
+			"//host/path?baz=qux", "bar",
+		},
+		{
+			"file:////host/path//bar",
+			"file:////host/path", "bar",
+		},
 	}
 
 	for i, tc := range cases {
@@ -104,3 +116,52 @@ func TestSourceSubdirGlob(t *testing.T) {
 		t.Fatalf("expected no matches, got %q", res)
 	}
 }
+
+func TestSplitForce(t *testing.T) {
+	cases := []struct {
+		Input           string
+		Force, Dir, Sub string
+	}{
+		{"hashicorp.com", "", "hashicorp.com", ""},
+		{
+			"git::https://hashicorp.com/foo.git",
+			"git", "https://hashicorp.com/foo.git", "",
+		},
+		{
+			"git::https://hashicorp.com/foo.git//bar?ref=v1",
+			"git", "https://hashicorp.com/foo.git?ref=v1", "bar",
+		},
+		{
+			" Git::git@hashicorp.com:foo.git//bar/baz?ref=v1&depth=1",
+			"git", "git@hashicorp.com:foo.git?ref=v1&depth=1", "bar/baz",
+		},
+		{
+			"s3::https://s3.amazonaws.com/bucket/foo//*?version=1",
+			"s3", "https://s3.amazonaws.com/bucket/foo?version=1", "*",
+		},
+		{
+			"git::git::./foo//bar",
+			"git", "git::./foo", "bar",
+		},
+		{
+			"::foo",
+			"", "::foo", "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			force, rest := SplitForce(tc.Input)
+			dir, sub := SourceDirSubdir(rest)
+			if force != tc.Force {
+				t.Errorf("bad force: %q, expected %q", force, tc.Force)
+			}
+			if dir != tc.Dir {
+				t.Errorf("bad dir: %q, expected %q", dir, tc.Dir)
+			}
+			if sub != tc.Sub {
+				t.Errorf("bad sub: %q, expected %q", sub, tc.Sub)
+			}
+		})
+	}
+}